@@ -17,12 +17,19 @@
 package conf
 
 import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/antonmedv/expr"
+	"github.com/limpo1989/go-spring/conf/internal"
+	"github.com/limpo1989/go-spring/internal/log"
 	"github.com/limpo1989/go-spring/internal/utils"
 )
 
@@ -33,17 +40,24 @@ var (
 
 // ParsedTag a value tag includes at most three parts: required key, optional
 // default value, and optional splitter, the syntax is ${key:=value}||splitter.
+// The key can be followed by candidate keys, like ${key|key2|key3:=value}, the
+// first present one of them is used.
 type ParsedTag struct {
-	Key      string // short property key
-	Def      string // default value
-	HasDef   bool   // has default value
-	Splitter string // splitter's name
+	Key        string   // short property key
+	Candidates []string // short candidate keys
+	Def        string   // default value
+	HasDef     bool     // has default value
+	Splitter   string   // splitter's name
 }
 
 func (tag ParsedTag) String() string {
 	var sb strings.Builder
 	sb.WriteString("${")
 	sb.WriteString(tag.Key)
+	for _, c := range tag.Candidates {
+		sb.WriteString("|")
+		sb.WriteString(c)
+	}
 	if tag.HasDef {
 		sb.WriteString(":=")
 		sb.WriteString(tag.Def)
@@ -57,27 +71,55 @@ func (tag ParsedTag) String() string {
 }
 
 // ParseTag parses a value tag, returns its key, and default value, and splitter.
+// The grammar it handles is:
+//
+//	tag      = "${" keys [ ":=" default ] "}" [ "||" splitter ]
+//	keys     = key { "|" key }
+//
+// The tag must start with "${", and the closing brace is the one matching it,
+// so the default value can contain nested references like ${a:=${b}} and a
+// "||" inside the braces is part of the key or default value, only the "||"
+// right after the closing brace starts a splitter. The key is split at the
+// first ":=", and candidate keys after the first one must not be empty.
 func ParseTag(tag string) (ret ParsedTag, err error) {
-	i := strings.LastIndex(tag, "||")
-	if i == 0 {
+	if !strings.HasPrefix(tag, "${") {
 		err = fmt.Errorf("parse tag '%s' error: %w", tag, errInvalidSyntax)
 		return
 	}
-	j := strings.LastIndex(tag, "}")
-	if j <= 0 {
-		err = fmt.Errorf("parse tag '%s' error: %w", tag, errInvalidSyntax)
-		return
+	count, end := 0, -1
+	for i := 0; i < len(tag) && end < 0; i++ {
+		if strings.HasPrefix(tag[i:], "${") {
+			count++
+			i++
+		} else if tag[i] == '}' {
+			if count--; count == 0 {
+				end = i
+			}
+		}
 	}
-	k := strings.Index(tag, "${")
-	if k < 0 {
+	if end < 0 {
 		err = fmt.Errorf("parse tag '%s' error: %w", tag, errInvalidSyntax)
 		return
 	}
-	if i > j {
-		ret.Splitter = strings.TrimSpace(tag[i+2:])
+	if rest := tag[end+1:]; rest != "" {
+		if !strings.HasPrefix(rest, "||") {
+			err = fmt.Errorf("parse tag '%s' error: %w", tag, errInvalidSyntax)
+			return
+		}
+		ret.Splitter = strings.TrimSpace(rest[2:])
+	}
+	ss := strings.SplitN(tag[2:end], ":=", 2)
+	keys := strings.Split(ss[0], "|")
+	ret.Key = keys[0]
+	if len(keys) > 1 {
+		for _, c := range keys[1:] {
+			if c == "" {
+				err = fmt.Errorf("parse tag '%s' error: %w", tag, errInvalidSyntax)
+				return
+			}
+		}
+		ret.Candidates = keys[1:]
 	}
-	ss := strings.SplitN(tag[k+2:j], ":=", 2)
-	ret.Key = ss[0]
 	if len(ss) > 1 {
 		ret.HasDef = true
 		ret.Def = ss[1]
@@ -85,67 +127,282 @@ func ParseTag(tag string) (ret ParsedTag, err error) {
 	return
 }
 
+// Validate checks the parsed tag, the keys should be valid property keys,
+// and the splitter should be registered.
+func (tag ParsedTag) Validate() error {
+	keys := append([]string{tag.Key}, tag.Candidates...)
+	for _, key := range keys {
+		// the key with a registered scheme is resolved by its ValueResolver.
+		if i := strings.Index(key, ":"); i > 0 && valueResolvers[key[:i]] != nil {
+			continue
+		}
+		if _, err := internal.SplitPath(key); err != nil {
+			return fmt.Errorf("validate tag '%s' error: %w", tag, err)
+		}
+	}
+	if tag.Splitter != "" && splitters[tag.Splitter] == nil {
+		return fmt.Errorf("validate tag '%s' error: splitter %q not found", tag, tag.Splitter)
+	}
+	return nil
+}
+
 type BindParam struct {
-	Key      string            // full key
-	Path     string            // full path
-	Tag      ParsedTag         // parsed tag
-	Validate reflect.StructTag // full field tag
+	Key        string            // full key
+	Path       string            // full path
+	Tag        ParsedTag         // parsed tag
+	Validate   reflect.StructTag // full field tag
+	Converter  string            // named converter
+	Aliases    []string          // full keys of deprecated names
+	Candidates []string          // full keys of candidate names
+	Owner      reflect.Value     // the struct owning the field
+
+	ZeroOnMissing bool // leaves the value zero when the key is absent
+	Lenient       bool // parses bools and integers leniently
+}
+
+// validate validates the value of the field, validators like expr can access
+// the sibling fields by `fields`.
+func (param *BindParam) validate(i interface{}) error {
+	var fields map[string]interface{}
+	if hasFieldsValidator(param.Validate) {
+		fields = param.fields()
+	}
+	return withPath(validateFields(param.Validate, i, fields), param.Path)
+}
+
+// fields returns the exported sibling fields of the field by their names, the
+// fields declared after it are not bound yet. It returns nil when the field
+// isn't a struct field.
+func (param *BindParam) fields() map[string]interface{} {
+	if !param.Owner.IsValid() {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	t := param.Owner.Type()
+	for j := 0; j < t.NumField(); j++ {
+		if ft := t.Field(j); ft.IsExported() {
+			fields[ft.Name] = param.Owner.Field(j).Interface()
+		}
+	}
+	return fields
+}
+
+// checkRequired reports an error when the field is required but neither the
+// key nor its candidates or aliases has a value in the properties, defaults
+// don't count, or empty is true, which is for the resolved value of leaves.
+// The 'required' tag is a bool, or an expr on the sibling fields like
+// `required:"fields.Enabled"`, which requires the field only when it returns
+// true.
+func (param *BindParam) checkRequired(p *Properties, empty bool) error {
+	tag, ok := param.Validate.Lookup("required")
+	if !ok || tag == "" {
+		return nil
+	}
+	required, perr := strconv.ParseBool(tag)
+	if perr != nil {
+		r, eerr := expr.Eval(tag, map[string]interface{}{"fields": param.fields()})
+		if eerr != nil {
+			return fmt.Errorf("eval %q returns: %w", tag, eerr)
+		}
+		if required, ok = r.(bool); !ok {
+			return fmt.Errorf("eval %q doesn't return bool", tag)
+		}
+	}
+	if required && (empty || !param.hasValue(p)) {
+		return fmt.Errorf("required property %q is empty", param.Key)
+	}
+	return nil
+}
+
+// hasValue returns whether the key, one of its candidates or aliases has a
+// non-empty value or any sub key in the properties, a key with a registered
+// scheme always has.
+func (param *BindParam) hasValue(p *Properties) bool {
+	if i := strings.Index(param.Tag.Key, ":"); i > 0 && valueResolvers[param.Tag.Key[:i]] != nil {
+		return true
+	}
+	keys := append([]string{param.Key}, param.Candidates...)
+	for _, key := range append(keys, param.Aliases...) {
+		if p.storage.Get(key) != "" {
+			return true
+		}
+		if subKeys, _ := p.storage.SubKeys(key); len(subKeys) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
 func (param *BindParam) BindTag(tag string, validate reflect.StructTag) error {
-	parsedTag, err := ParseTag(tag)
+	parsedTag, err := parseValueTag(tag)
 	if err != nil {
 		return err
 	}
+	param.bindParsedTag(parsedTag, validate)
+	return nil
+}
+
+// parseValueTag parses a 'value' tag, and normalizes its key.
+func parseValueTag(tag string) (ParsedTag, error) {
+	parsedTag, err := ParseTag(tag)
+	if err != nil {
+		return ParsedTag{}, err
+	}
 	if parsedTag.Key == "ROOT" {
 		parsedTag.Key = ""
 	} else if parsedTag.Key == "" {
 		parsedTag.Key = "ANONYMOUS"
 	}
+	return parsedTag, nil
+}
+
+func (param *BindParam) bindParsedTag(parsedTag ParsedTag, validate reflect.StructTag) {
 	param.Tag = parsedTag
+	prefix := param.Key
 	if param.Key == "" {
 		param.Key = parsedTag.Key
 	} else if parsedTag.Key != "" {
 		param.Key = param.Key + "." + parsedTag.Key
 	}
+	for _, c := range parsedTag.Candidates {
+		if prefix != "" {
+			c = prefix + "." + c
+		}
+		param.Candidates = append(param.Candidates, c)
+	}
 	param.Validate = validate
-	return nil
+	param.Converter = validate.Get("converter")
+	if alias, ok := validate.Lookup("alias"); ok {
+		for _, s := range strings.Split(alias, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			if prefix != "" {
+				s = prefix + "." + s
+			}
+			param.Aliases = append(param.Aliases, s)
+		}
+	}
 }
 
 type Filter func(i interface{}, param BindParam) (bool, error)
 
+// FilterChain returns a Filter running the filters in order. It stops at the
+// first filter that handles the field or returns an error, so that the later
+// filters don't run on the field, and returns its result. Nil filters are
+// skipped.
+func FilterChain(filters ...Filter) Filter {
+	return func(i interface{}, param BindParam) (bool, error) {
+		for _, f := range filters {
+			if f == nil {
+				continue
+			}
+			if ok, err := f(i, param); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+}
+
 // BindValue binds properties to a value.
 func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam, filter Filter) error {
 
-	if !utils.IsValueType(t) {
-		err := errors.New("target should be value type")
-		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	// a Dynamic value is bound as its value type, and stored as a whole.
+	if v.CanAddr() {
+		if d, ok := v.Addr().Interface().(dynamicValue); ok {
+			nv := reflect.New(d.valueType()).Elem()
+			if err := BindValue(p, nv, nv.Type(), param, filter); err != nil {
+				return err
+			}
+			d.setValue(nv)
+			return nil
+		}
 	}
 
-	switch v.Kind() {
-	case reflect.Map:
-		return bindMap(p, v, t, param, filter)
-	case reflect.Slice:
-		return bindSlice(p, v, t, param, filter)
-	case reflect.Array:
-		err := errors.New("use slice instead of array")
+	// converters take precedence, so that types like net.IP ([]byte) or
+	// *net.IPNet can be bound from their textual forms.
+	fn := converters[t]
+
+	// the named converter picked by tag takes precedence over the type-keyed
+	// one, for slice or map it applies to the elements.
+	if param.Converter != "" {
+		c, ok := namedConverters[param.Converter]
+		if !ok {
+			err := fmt.Errorf("converter %q not found", param.Converter)
+			return fmt.Errorf("bind %s error: %w", param.Path, err)
+		}
+		if ct := reflect.TypeOf(c).Out(0); ct == t {
+			fn = c
+		} else if k := v.Kind(); (k != reflect.Slice && k != reflect.Map) || ct != t.Elem() {
+			err := fmt.Errorf("converter %q can't convert to %s", param.Converter, t.String())
+			return fmt.Errorf("bind %s error: %w", param.Path, err)
+		}
+	}
+
+	// types implementing encoding.TextUnmarshaler are bound from text, such as
+	// uuid.UUID, unless a converter is registered for them.
+	if fn == nil && isTextUnmarshaler(t) {
+		return bindText(p, v, t, param)
+	}
+
+	// interface{} is bound with the type inferred from the properties.
+	if fn == nil && isEmptyInterface(t) {
+		return bindInterface(p, v, param, filter)
+	}
+
+	if !isValueType(t) {
+		err := errors.New("target should be value type")
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	}
 
-	fn := converters[t]
-	if fn == nil && v.Kind() == reflect.Struct {
-		if err := bindStruct(p, v, t, param, filter); err != nil {
-			//return fmt.Errorf("bind %s error: %w", param.Path, err)
-			return err
+	if fn == nil {
+		switch v.Kind() {
+		case reflect.Map:
+			return bindMap(p, v, t, param, filter)
+		case reflect.Slice:
+			// []byte is decoded from a string only with an encoding tag,
+			// and never when it's given as a list.
+			if param.Converter != "" || t.Elem().Kind() != reflect.Uint8 ||
+				param.Validate.Get("encoding") == "" || p.storage.Has(param.Key+"[0]") {
+				return bindSlice(p, v, t, param, filter)
+			}
+		case reflect.Array:
+			err := errors.New("use slice instead of array")
+			return fmt.Errorf("bind %s error: %w", param.Path, err)
+		case reflect.Struct:
+			if err := bindStruct(p, v, t, param, filter); err != nil {
+				//return fmt.Errorf("bind %s error: %w", param.Path, err)
+				return err
+			}
+			return nil
 		}
-		return nil
 	}
 
 	val, err := resolve(p, param)
+	if err := param.checkRequired(p, err == nil && val == ""); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
 	if err != nil {
+		if isZeroOnMissing(p, param, err) {
+			return nil
+		}
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	}
 
+	// an explicit empty default like ${key:=} binds the zero value, as slices
+	// and maps bind empty ones.
+	if val == "" && param.Tag.HasDef && param.Tag.Def == "" && !p.storage.Has(param.Key) {
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			val = "0"
+		case reflect.Bool:
+			val = "false"
+		}
+	}
+
 	if fn != nil {
 		fnValue := reflect.ValueOf(fn)
 		out := fnValue.Call([]reflect.Value{reflect.ValueOf(val)})
@@ -154,7 +411,7 @@ func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 			return fmt.Errorf("bind %s error: %w", param.Path, err)
 		}
 
-		if err = Validate(param.Validate, out[0].Interface()); nil != err {
+		if err = param.validate(out[0].Interface()); nil != err {
 			return fmt.Errorf("validate %s error: %w", param.Path, err)
 		}
 
@@ -165,8 +422,8 @@ func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 	switch v.Kind() {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		var u uint64
-		if u, err = strconv.ParseUint(val, 0, 0); err == nil {
-			if err = Validate(param.Validate, u); err != nil {
+		if u, err = strconv.ParseUint(numberString(val, param.Lenient), 0, 0); err == nil {
+			if err = param.validate(u); err != nil {
 				return fmt.Errorf("validate %s error: %w", param.Path, err)
 			}
 			v.SetUint(u)
@@ -175,8 +432,8 @@ func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		var i int64
-		if i, err = strconv.ParseInt(val, 0, 0); err == nil {
-			if err = Validate(param.Validate, i); err != nil {
+		if i, err = strconv.ParseInt(numberString(val, param.Lenient), 0, 0); err == nil {
+			if err = param.validate(i); err != nil {
 				return fmt.Errorf("validate %s error: %w", param.Path, err)
 			}
 			v.SetInt(i)
@@ -186,7 +443,7 @@ func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 	case reflect.Float32, reflect.Float64:
 		var f float64
 		if f, err = strconv.ParseFloat(val, 64); err == nil {
-			if err = Validate(param.Validate, f); err != nil {
+			if err = param.validate(f); err != nil {
 				return fmt.Errorf("validate %s error: %w", param.Path, err)
 			}
 			v.SetFloat(f)
@@ -195,8 +452,8 @@ func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	case reflect.Bool:
 		var b bool
-		if b, err = strconv.ParseBool(val); err == nil {
-			if err = Validate(param.Validate, b); err != nil {
+		if b, err = parseBool(val, param.Lenient); err == nil {
+			if err = param.validate(b); err != nil {
 				return fmt.Errorf("validate %s error: %w", param.Path, err)
 			}
 			v.SetBool(b)
@@ -204,25 +461,236 @@ func BindValue(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 		}
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	case reflect.String:
-		if err = Validate(param.Validate, val); err != nil {
+		if val, err = transformString(val, param.Validate.Get("transform")); err != nil {
+			return fmt.Errorf("bind %s error: %w", param.Path, err)
+		}
+		if err = param.validate(val); err != nil {
 			return fmt.Errorf("validate %s error: %w", param.Path, err)
 		}
 		v.SetString(val)
 		return nil
+	case reflect.Slice:
+		var b []byte
+		if b, err = decodeBytes(val, param.Validate.Get("encoding")); err == nil {
+			if err = param.validate(b); err != nil {
+				return fmt.Errorf("validate %s error: %w", param.Path, err)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	}
 
 	err = fmt.Errorf("unsupported bind type %q", t.String())
 	return fmt.Errorf("bind %s error: %w", param.Path, err)
 }
 
+// parseBool parses a bool value like strconv.ParseBool, the lenient mode also
+// accepts yes/no and on/off in any case.
+func parseBool(s string, lenient bool) (bool, error) {
+	if lenient {
+		s = strings.TrimSpace(s)
+		switch strings.ToLower(s) {
+		case "yes", "on":
+			return true, nil
+		case "no", "off":
+			return false, nil
+		}
+	}
+	return strconv.ParseBool(s)
+}
+
+// numberString returns the integer value without the thousands separators
+// like 1,000 in the lenient mode, underscores like 1_000 are always accepted.
+func numberString(s string, lenient bool) string {
+	if lenient {
+		s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	}
+	return s
+}
+
+// isZeroOnMissing returns whether the error is caused by the absent key which
+// is allowed by ZeroOnMissing.
+func isZeroOnMissing(p *Properties, param BindParam, err error) bool {
+	return param.ZeroOnMissing && errors.Is(err, errNotExist) && !p.storage.Has(param.Key)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isTextUnmarshaler returns whether the pointer of t implements the interface
+// encoding.TextUnmarshaler.
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Kind() != reflect.Ptr && reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// bindText binds properties to a value whose type implements the interface
+// encoding.TextUnmarshaler.
+func bindText(p *Properties, v reflect.Value, t reflect.Type, param BindParam) error {
+	val, err := resolve(p, param)
+	if err := param.checkRequired(p, err == nil && val == ""); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+	if err != nil {
+		if isZeroOnMissing(p, param, err) {
+			return nil
+		}
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+	ptr := reflect.New(t)
+	if err = ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val)); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+	if err = param.validate(ptr.Elem().Interface()); err != nil {
+		return fmt.Errorf("validate %s error: %w", param.Path, err)
+	}
+	v.Set(ptr.Elem())
+	return nil
+}
+
+var (
+	interfaceSliceType = reflect.TypeOf([]interface{}(nil))
+	interfaceMapType   = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+// isEmptyInterface returns whether t is interface{}.
+func isEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+// bindInterface binds properties to an interface{} value. A list binds
+// []interface{}, a map binds map[string]interface{}, whose elements are bound
+// in the same way, and a value binds the type inferred by inferValue.
+func bindInterface(p *Properties, v reflect.Value, param BindParam, filter Filter) error {
+
+	var t reflect.Type
+	if keys, err := p.storage.SubKeys(param.Key); err == nil && len(keys) > 0 {
+		if p.storage.Has(param.Key + "[0]") {
+			t = interfaceSliceType
+		} else {
+			t = interfaceMapType
+		}
+	}
+	if t != nil {
+		e := reflect.New(t).Elem()
+		if err := BindValue(p, e, t, param, filter); err != nil {
+			return err
+		}
+		v.Set(e)
+		return nil
+	}
+
+	val, err := resolve(p, param)
+	if err := param.checkRequired(p, err == nil && val == ""); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+	if err != nil {
+		if isZeroOnMissing(p, param, err) {
+			return nil
+		}
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+	i := inferValue(val)
+	if err = param.validate(i); err != nil {
+		return fmt.Errorf("validate %s error: %w", param.Path, err)
+	}
+	v.Set(reflect.ValueOf(i))
+	return nil
+}
+
+// inferValue infers the type of a string value, true and false are bool,
+// integers are int64, other numbers are float64, and the others are string.
+func inferValue(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	// excludes the special values like NaN and Inf, which are more likely
+	// to be strings in properties.
+	if strings.ContainsAny(s, "0123456789") {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+// decodeBytes decodes a string into []byte by the encoding, base64 or hex.
+func decodeBytes(s string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.DecodeString(s)
+	case "hex":
+		return hex.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+// transformString applies the comma separated transforms in order to a string
+// value before it's validated, they are trim, lower and upper.
+func transformString(s string, transforms string) (string, error) {
+	if transforms == "" {
+		return s, nil
+	}
+	for _, name := range strings.Split(transforms, ",") {
+		switch strings.TrimSpace(name) {
+		case "trim":
+			s = strings.TrimSpace(s)
+		case "lower":
+			s = strings.ToLower(s)
+		case "upper":
+			s = strings.ToUpper(s)
+		default:
+			return "", fmt.Errorf("unsupported transform %q", name)
+		}
+	}
+	return s, nil
+}
+
+// isValueType returns whether t is a value type, or a type whose values (or
+// elements) can be converted by a registered converter.
+func isValueType(t reflect.Type) bool {
+	if converters[t] != nil {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		et := t.Elem()
+		if converters[et] != nil || isEmptyInterface(et) {
+			return true
+		}
+		// map values can be composite themselves, such as map[string][]int.
+		if t.Kind() == reflect.Map {
+			switch et.Kind() {
+			case reflect.Map, reflect.Slice, reflect.Array:
+				return isValueType(et)
+			}
+		}
+	}
+	return utils.IsValueType(t)
+}
+
 // bindSlice binds properties to a slice value.
 func bindSlice(p *Properties, v reflect.Value, t reflect.Type, param BindParam, filter Filter) error {
 
+	if err := param.checkRequired(p, false); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+
 	et := t.Elem()
-	p, err := getSlice(p, et, param)
+	sp, err := getSlice(p, et, param)
 	if err != nil {
+		if isZeroOnMissing(p, param, err) {
+			return nil
+		}
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	}
+	p = sp
 
 	slice := reflect.MakeSlice(t, 0, 0)
 
@@ -234,20 +702,30 @@ func bindSlice(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 	for i := 0; ; i++ {
 		e := reflect.New(et).Elem()
 		subParam := BindParam{
-			Key:  fmt.Sprintf("%s[%d]", param.Key, i),
-			Path: fmt.Sprintf("%s[%d]", param.Path, i),
+			Key:           fmt.Sprintf("%s[%d]", param.Key, i),
+			Path:          fmt.Sprintf("%s[%d]", param.Path, i),
+			Converter:     param.Converter,
+			ZeroOnMissing: param.ZeroOnMissing,
+			Lenient:       param.Lenient,
 		}
-		err = BindValue(p, e, et, subParam, filter)
-		if errors.Is(err, errNotExist) {
+		if !p.storage.Has(subParam.Key) {
 			break
 		}
+		err = BindValue(p, e, et, subParam, filter)
 		if err != nil {
 			return fmt.Errorf("bind %s error: %w", param.Path, err)
 		}
 		slice = reflect.Append(slice, e)
 	}
 
-	if err = Validate(param.Validate, slice.Interface()); nil != err {
+	// lists must be contiguous, e.g. a[0] and a[2] without a[1] is an error
+	// instead of silently dropping a[2].
+	if keys, _ := p.storage.SubKeys(param.Key); len(keys) > slice.Len() {
+		err = fmt.Errorf("property %q misses index %d", param.Key, slice.Len())
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+
+	if err = param.validate(slice.Interface()); nil != err {
 		return fmt.Errorf("validate %s error: %w", param.Path, err)
 	}
 
@@ -262,6 +740,11 @@ func getSlice(p *Properties, et reflect.Type, param BindParam) (*Properties, err
 		return p, nil
 	}
 
+	// a list which starts with a gap, e.g. a[1] without a[0].
+	if keys, _ := p.storage.SubKeys(param.Key); len(keys) > 0 {
+		return nil, fmt.Errorf("property %q misses index 0", param.Key)
+	}
+
 	// properties defined as string and needs to split into []string.
 	var strVal string
 	{
@@ -274,7 +757,7 @@ func getSlice(p *Properties, et reflect.Type, param BindParam) (*Properties, err
 			if param.Tag.Def == "" {
 				return nil, nil
 			}
-			if !utils.IsPrimitiveValueType(et) && converters[et] == nil {
+			if !utils.IsPrimitiveValueType(et) && converters[et] == nil && !isTextUnmarshaler(et) && !isEmptyInterface(et) {
 				return nil, fmt.Errorf("slice can't have a non empty default value")
 			}
 			strVal = param.Tag.Def
@@ -284,12 +767,19 @@ func getSlice(p *Properties, et reflect.Type, param BindParam) (*Properties, err
 		return nil, nil
 	}
 
-	var (
-		err    error
-		arrVal []string
-	)
+	// references are resolved before splitting, as the split elements are
+	// bound from a new Properties which has no other properties.
+	strVal, err := resolveString(p, strVal)
+	if err != nil {
+		return nil, err
+	}
 
-	if s := param.Tag.Splitter; s == "" {
+	var arrVal []string
+	if s := param.Tag.Splitter; s == "" && p.splitter != nil {
+		if arrVal, err = p.splitter(strVal); err != nil {
+			return nil, fmt.Errorf("split error: %w", err)
+		}
+	} else if s == "" {
 		arrVal = strings.Split(strVal, ",")
 		for i := range arrVal {
 			arrVal[i] = strings.TrimSpace(arrVal[i])
@@ -302,10 +792,12 @@ func getSlice(p *Properties, et reflect.Type, param BindParam) (*Properties, err
 		return nil, fmt.Errorf("error splitter '%s'", s)
 	}
 
+	// the elements are resolved already, so their ${ are escaped to keep them
+	// literal when the elements are bound.
 	p = New()
 	for i, s := range arrVal {
 		k := fmt.Sprintf("%s[%d]", param.Key, i)
-		_ = p.store(k, s)
+		_ = p.store(k, strings.ReplaceAll(s, "${", "$${"))
 	}
 	return p, nil
 }
@@ -313,6 +805,10 @@ func getSlice(p *Properties, et reflect.Type, param BindParam) (*Properties, err
 // bindMap binds properties to a map value.
 func bindMap(p *Properties, v reflect.Value, t reflect.Type, param BindParam, filter Filter) (err error) {
 
+	if err := param.checkRequired(p, false); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+
 	if param.Tag.HasDef && param.Tag.Def != "" {
 		err := errors.New("map can't have a non empty default value")
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
@@ -333,8 +829,11 @@ func bindMap(p *Properties, v reflect.Value, t reflect.Type, param BindParam, fi
 			subKey = param.Key + "." + key
 		}
 		subParam := BindParam{
-			Key:  subKey,
-			Path: param.Path,
+			Key:           subKey,
+			Path:          param.Path,
+			Converter:     param.Converter,
+			ZeroOnMissing: param.ZeroOnMissing,
+			Lenient:       param.Lenient,
 		}
 		err = BindValue(p, e, et, subParam, filter)
 		if err != nil {
@@ -343,7 +842,7 @@ func bindMap(p *Properties, v reflect.Value, t reflect.Type, param BindParam, fi
 		ret.SetMapIndex(reflect.ValueOf(key), e)
 	}
 
-	if err = Validate(param.Validate, ret.Interface()); nil != err {
+	if err = param.validate(ret.Interface()); nil != err {
 		return fmt.Errorf("validate %s error: %w", param.Path, err)
 	}
 
@@ -351,31 +850,122 @@ func bindMap(p *Properties, v reflect.Value, t reflect.Type, param BindParam, fi
 	return nil
 }
 
+const (
+	fieldTagged = iota // field with 'value' tag
+	fieldEmbed         // anonymous struct field
+	fieldValue         // value type field without 'value' tag
+)
+
+// fieldPlan is the cached binding plan of a struct field.
+type fieldPlan struct {
+	index    int
+	kind     int
+	name     string
+	typ      reflect.Type
+	key      string            // key of the fieldValue field
+	tag      ParsedTag         // parsed tag of the fieldTagged field
+	tagErr   error             // error of parsing the tag
+	validate reflect.StructTag // full field tag
+	exported bool
+}
+
+// structPlans caches the binding plans keyed by struct type, it's reset when
+// something that plans depend on changes, such as registered converters.
+var structPlans sync.Map
+
+// resetStructPlans drops all cached binding plans.
+func resetStructPlans() {
+	structPlans.Range(func(k, _ interface{}) bool {
+		structPlans.Delete(k)
+		return true
+	})
+}
+
+// structPlanOf returns the binding plan of the struct type t, builds it if
+// it's not cached.
+func structPlanOf(t reflect.Type) []fieldPlan {
+	if plan, ok := structPlans.Load(t); ok {
+		return plan.([]fieldPlan)
+	}
+	var plan []fieldPlan
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		f := fieldPlan{index: i, name: ft.Name, typ: ft.Type, validate: ft.Tag, exported: ft.IsExported()}
+		if tag, ok := ft.Tag.Lookup("value"); ok {
+			// `value:"-"` means the field is ignored explicitly.
+			if tag == "-" {
+				continue
+			}
+			f.kind = fieldTagged
+			f.tag, f.tagErr = parseValueTag(tag)
+			plan = append(plan, f)
+			continue
+		}
+		if ft.Anonymous {
+			// embed pointer type may lead to infinite recursion.
+			if ft.Type.Kind() == reflect.Struct {
+				f.kind = fieldEmbed
+				plan = append(plan, f)
+			}
+			continue
+		}
+		if isValueType(ft.Type) {
+			var ok bool
+			if f.key, ok = fieldKey(ft); ok {
+				f.kind = fieldValue
+				plan = append(plan, f)
+			}
+		}
+	}
+	structPlans.Store(t, plan)
+	return plan
+}
+
 // bindStruct binds properties to a struct value.
 func bindStruct(p *Properties, v reflect.Value, t reflect.Type, param BindParam, filter Filter) error {
 
+	if err := param.checkRequired(p, false); err != nil {
+		return fmt.Errorf("bind %s error: %w", param.Path, err)
+	}
+
 	if param.Tag.HasDef && param.Tag.Def != "" {
 		err := errors.New("struct can't have a non empty default value")
 		return fmt.Errorf("bind %s error: %w", param.Path, err)
 	}
 
-	for i := 0; i < t.NumField(); i++ {
-		ft := t.Field(i)
-		fv := v.Field(i)
+	// failures of validation are collected when SetValidateAll is enabled,
+	// so that all the invalid fields are reported together.
+	var failures ValidationErrors
+	collect := func(err error) bool {
+		if !validateAll {
+			return false
+		}
+		e, ok := asValidationErrors(err)
+		failures = append(failures, e...)
+		return ok
+	}
+
+	for _, f := range structPlanOf(t) {
+		fv := v.Field(f.index)
 
 		if !fv.CanInterface() {
 			fv = utils.PatchValue(fv)
 		}
 
 		subParam := BindParam{
-			Key:  param.Key,
-			Path: param.Path + "." + ft.Name,
+			Key:           param.Key,
+			Path:          param.Path + "." + f.name,
+			Owner:         v,
+			ZeroOnMissing: param.ZeroOnMissing,
+			Lenient:       param.Lenient,
 		}
 
-		if tag, ok := ft.Tag.Lookup("value"); ok {
-			if err := subParam.BindTag(tag, ft.Tag); err != nil {
-				return fmt.Errorf("bind %s error: %w", param.Path, err)
+		switch f.kind {
+		case fieldTagged:
+			if f.tagErr != nil {
+				return fmt.Errorf("bind %s error: %w", param.Path, f.tagErr)
 			}
+			subParam.bindParsedTag(f.tag, f.validate)
 			if filter != nil {
 				ret, err := filter(fv.Addr().Interface(), subParam)
 				if err != nil {
@@ -385,44 +975,94 @@ func bindStruct(p *Properties, v reflect.Value, t reflect.Type, param BindParam,
 					continue
 				}
 			}
-			if err := BindValue(p, fv, ft.Type, subParam, filter); err != nil {
+			if err := BindValue(p, fv, f.typ, subParam, filter); err != nil && !collect(err) {
 				return fmt.Errorf("bind %s error: %w", param.Path, err)
 			}
-			continue
-		}
-
-		if ft.Anonymous {
-			// embed pointer type may lead to infinite recursion.
-			if ft.Type.Kind() != reflect.Struct {
-				continue
+		case fieldEmbed:
+			if err := bindStruct(p, fv, f.typ, subParam, filter); err != nil && !collect(err) {
+				return fmt.Errorf("bind %s error: %w", param.Path, err)
 			}
-			if err := bindStruct(p, fv, ft.Type, subParam, filter); err != nil {
+		case fieldValue:
+			if strictStruct && f.exported {
+				err := fmt.Errorf("field %s has neither 'value' tag nor `value:\"-\"`", f.name)
 				return fmt.Errorf("bind %s error: %w", param.Path, err)
 			}
-			continue
-		}
-
-		if utils.IsValueType(ft.Type) {
 			if subParam.Key == "" {
-				subParam.Key = ft.Name
+				subParam.Key = f.key
 			} else {
-				subParam.Key = subParam.Key + "." + ft.Name
+				subParam.Key = subParam.Key + "." + f.key
 			}
-			if err := BindValue(p, fv, ft.Type, subParam, filter); err != nil {
+			if err := BindValue(p, fv, f.typ, subParam, filter); err != nil && !collect(err) {
 				return fmt.Errorf("bind %s error: %w", param.Path, err)
 			}
 		}
 	}
+	if len(failures) > 0 {
+		return fmt.Errorf("bind %s error: %w", param.Path, failures)
+	}
 	return nil
 }
 
+// fieldKey returns the key of a field without 'value' tag, it's the field name,
+// or the name of its 'json' tag when SetUseJSONTagAsKey is enabled. It returns
+// false when the field is ignored by `json:"-"`.
+func fieldKey(ft reflect.StructField) (string, bool) {
+	if !useJSONTagAsKey {
+		return ft.Name, true
+	}
+	tag, ok := ft.Tag.Lookup("json")
+	if !ok {
+		return ft.Name, true
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		return ft.Name, true
+	}
+	return name, true
+}
+
 // resolve returns property references processed property value.
 func resolve(p *Properties, param BindParam) (string, error) {
+	return resolveParam(p, param, nil)
+}
+
+// resolveParam returns property references processed property value, keys
+// is the chain of keys being resolved, which is used to detect cycles.
+func resolveParam(p *Properties, param BindParam, keys []string) (string, error) {
+	// the key with a registered scheme is resolved by its ValueResolver.
+	if i := strings.Index(param.Tag.Key, ":"); i > 0 {
+		if fn, ok := valueResolvers[param.Tag.Key[:i]]; ok {
+			return fn(param.Tag.Key[i+1:])
+		}
+	}
 	if val := p.storage.Get(param.Key); val != "" {
-		return resolveString(p, val)
+		return resolveValue(p, param.Key, val, keys)
+	}
+	// tries the candidate keys in order when the key doesn't exist.
+	if len(param.Candidates) > 0 && !p.storage.Has(param.Key) {
+		for _, key := range param.Candidates {
+			if p.storage.Has(key) {
+				return resolveValue(p, key, p.storage.Get(key), keys)
+			}
+		}
+	}
+	// falls back to the deprecated names when the key doesn't exist.
+	if len(param.Aliases) > 0 && !p.storage.Has(param.Key) {
+		for _, alias := range param.Aliases {
+			if !p.storage.Has(alias) {
+				continue
+			}
+			if l := deprecationLogger(p); l != nil {
+				l.Warn(fmt.Sprintf("property %q is deprecated, use %q instead", alias, param.Key))
+			}
+			return resolveValue(p, alias, p.storage.Get(alias), keys)
+		}
 	}
 	if param.Tag.HasDef {
-		return resolveString(p, param.Tag.Def)
+		return resolveStringWith(p, param.Tag.Def, keys)
 	}
 	if p.storage.Has(param.Key) {
 		return "", nil
@@ -430,8 +1070,31 @@ func resolve(p *Properties, param BindParam) (string, error) {
 	return "", fmt.Errorf("property %q: %w", param.Key, errNotExist)
 }
 
+// deprecationLogger returns the logger of the warnings on deprecated names,
+// which is the primary logger, unit tests replace it to check the warnings.
+var deprecationLogger = func(p *Properties) *log.Logger {
+	return log.GetLogger("", utils.TypeName(p))
+}
+
+// resolveValue returns property references processed value of the key.
+func resolveValue(p *Properties, key, val string, keys []string) (string, error) {
+	for i, k := range keys {
+		if k == key {
+			cycle := strings.Join(append(keys[i:], key), " -> ")
+			return "", fmt.Errorf("found cycle reference %s", cycle)
+		}
+	}
+	return resolveStringWith(p, val, append(keys, key))
+}
+
 // resolveString returns property references processed string.
 func resolveString(p *Properties, s string) (string, error) {
+	return resolveStringWith(p, s, nil)
+}
+
+// resolveStringWith returns property references processed string, keys is
+// the chain of keys being resolved.
+func resolveStringWith(p *Properties, s string, keys []string) (string, error) {
 
 	var (
 		length = len(s)
@@ -441,6 +1104,14 @@ func resolveString(p *Properties, s string) (string, error) {
 	)
 
 	for i := 0; i < length; i++ {
+		// $${ is the escape of a literal ${.
+		if count == 0 && strings.HasPrefix(s[i:], "$${") {
+			s1, err := resolveStringWith(p, s[i+3:], keys)
+			if err != nil {
+				return "", fmt.Errorf("resolve string %q error: %w", s, err)
+			}
+			return s[:i] + "${" + s1, nil
+		}
 		if s[i] == '$' {
 			if i < length-1 && s[i+1] == '{' {
 				if count == 0 {
@@ -470,12 +1141,12 @@ func resolveString(p *Properties, s string) (string, error) {
 	var param BindParam
 	_ = param.BindTag(s[start:end+1], "")
 
-	s1, err := resolve(p, param)
+	s1, err := resolveParam(p, param, keys)
 	if err != nil {
 		return "", fmt.Errorf("resolve string %q error: %w", s, err)
 	}
 
-	s2, err := resolveString(p, s[end+1:])
+	s2, err := resolveStringWith(p, s[end+1:], keys)
 	if err != nil {
 		return "", fmt.Errorf("resolve string %q error: %w", s, err)
 	}
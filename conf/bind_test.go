@@ -17,13 +17,19 @@
 package conf
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/limpo1989/go-spring/internal/log"
 	"github.com/limpo1989/go-spring/internal/utils/assert"
 )
 
@@ -99,6 +105,38 @@ func TestParseTag(t *testing.T) {
 			Tag:  "${a:=b}||k",
 			Data: "${a:=b}||k",
 		},
+		{
+			Tag:  "${a|b|c:=d}||k",
+			Data: "${a|b|c:=d}||k",
+		},
+		{
+			Tag:  "${a:=b||c}||k",
+			Data: "${a:=b||c}||k",
+		},
+		{
+			Tag:  "${a:=${b:=c}}",
+			Data: "${a:=${b:=c}}",
+		},
+		{
+			Tag:   "${a||b}",
+			Error: `parse tag '\$\{a\|\|b\}' error: invalid syntax`,
+		},
+		{
+			Tag:   "${a:=${b}",
+			Error: `parse tag '\$\{a:=\$\{b\}' error: invalid syntax`,
+		},
+		{
+			Tag:   "x${a}",
+			Error: `parse tag 'x\$\{a\}' error: invalid syntax`,
+		},
+		{
+			Tag:   "${a}x||k",
+			Error: `parse tag '\$\{a\}x\|\|k' error: invalid syntax`,
+		},
+		{
+			Tag:   "${a|:=d}",
+			Error: `parse tag '\$\{a\|:=d\}' error: invalid syntax`,
+		},
 	}
 	for _, c := range testcases {
 		tag, err := ParseTag(c.Tag)
@@ -114,6 +152,50 @@ func TestParseTag(t *testing.T) {
 	}
 }
 
+func TestParsedTag_Validate(t *testing.T) {
+
+	tag, err := ParseTag("${a.b[0]|c:=d}||splitter")
+	assert.Nil(t, err)
+	assert.Error(t, tag.Validate(), "validate tag '.*' error: splitter \"splitter\" not found")
+
+	RegisterSplitter("splitter", func(s string) ([]string, error) {
+		return strings.Split(s, ";"), nil
+	})
+	defer RemoveSplitter("splitter")
+	assert.Nil(t, tag.Validate())
+
+	tag, err = ParseTag("${a b}")
+	assert.Nil(t, err)
+	assert.Error(t, tag.Validate(), "validate tag '\\$\\{a b\\}' error: invalid key 'a b'")
+
+	tag, err = ParseTag("${a|b]}")
+	assert.Nil(t, err)
+	assert.Error(t, tag.Validate(), "invalid key 'b]'")
+}
+
+func FuzzParseTag(f *testing.F) {
+	for _, s := range []string{
+		"${a}", "${a:=b}||k", "${a|b|c:=d}", "${a:=${b:=${c}}}", "${a||b}",
+		"${a", "a}", "||", "${}||", "${a:=}}||k", "${${}}", "$${a}", "${a:=b||c}",
+	} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		tag, err := ParseTag(s)
+		if err != nil {
+			return
+		}
+		_ = tag.Validate()
+		r, err := ParseTag(tag.String())
+		if err != nil {
+			t.Fatalf("parse %q from %q error: %v", tag.String(), s, err)
+		}
+		if !reflect.DeepEqual(r, tag) {
+			t.Fatalf("parse %q from %q got %#v but expect %#v", tag.String(), s, r, tag)
+		}
+	})
+}
+
 func TestBindTag(t *testing.T) {
 
 	param := BindParam{}
@@ -499,6 +581,64 @@ func TestBind_SingleValue(t *testing.T) {
 
 func TestBind_SliceValue(t *testing.T) {
 
+	t.Run("gap", func(t *testing.T) {
+		p, err := Bytes([]byte("a[0]=1\na[1]=2\nb[0]=1\nb[2]=3\nc[1]=2\n"), ".properties")
+		assert.Nil(t, err)
+
+		var s []int
+		err = p.Bind(&s, Key("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, s, []int{1, 2})
+
+		err = p.Bind(&s, Key("b"))
+		assert.Error(t, err, "bind \\[\\]int error: property \"b\" misses index 1")
+
+		err = p.Bind(&s, Key("c"))
+		assert.Error(t, err, "bind \\[\\]int error: property \"c\" misses index 0")
+	})
+
+	t.Run("reference", func(t *testing.T) {
+		p := Map(map[string]interface{}{
+			"default.host": "a.com",
+			"backup.hosts": "b.com,c.com",
+			"hosts":        "${default.host},d.com",
+		})
+
+		var s []string
+		err := p.Bind(&s, Tag("${none:=${default.host}}"))
+		assert.Nil(t, err)
+		assert.Equal(t, s, []string{"a.com"})
+
+		err = p.Bind(&s, Tag("${none:=${backup.hosts},${default.host}}"))
+		assert.Nil(t, err)
+		assert.Equal(t, s, []string{"b.com", "c.com", "a.com"})
+
+		err = p.Bind(&s, Key("hosts"))
+		assert.Nil(t, err)
+		assert.Equal(t, s, []string{"a.com", "d.com"})
+
+		err = p.Bind(&s, Tag("${none:=${missing}}"))
+		assert.Error(t, err, "property \"missing\": not exist")
+
+		// the escaped ${ is resolved only once, like for strings.
+		p = Map(map[string]interface{}{
+			"escaped": "x,$${HOME}",
+			"nested":  "${escaped},y",
+		})
+		err = p.Bind(&s, Key("escaped"))
+		assert.Nil(t, err)
+		assert.Equal(t, s, []string{"x", "${HOME}"})
+
+		err = p.Bind(&s, Key("nested"))
+		assert.Nil(t, err)
+		assert.Equal(t, s, []string{"x", "${HOME}", "y"})
+
+		var str string
+		err = p.Bind(&str, Key("escaped"))
+		assert.Nil(t, err)
+		assert.Equal(t, str, "x,${HOME}")
+	})
+
 	t.Run("uints", func(t *testing.T) {
 		var u []uint
 
@@ -717,6 +857,14 @@ func TestBind_SliceValue(t *testing.T) {
 			},
 			"structs[2]": "",
 		}).Bind(&s, tag)
+		assert.Error(t, err, "bind \\[\\]conf.CommonStruct error: property \"structs\" misses index 1")
+
+		err = Map(map[string]interface{}{
+			"structs[0]": map[string]interface{}{
+				"int":  3,
+				"ints": "1,2,3",
+			},
+		}).Bind(&s, tag)
 		assert.Nil(t, err)
 		assert.Equal(t, s, []CommonStruct{
 			{
@@ -1026,6 +1174,67 @@ func TestBind_MapValue(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, m, expect)
 	})
+
+	t.Run("slice", func(t *testing.T) {
+		var m map[string][]int
+		err := Map(map[string]interface{}{
+			"map": map[string]interface{}{
+				"a": []int{1, 2},
+				"b": "3,4,5",
+			},
+		}).Bind(&m, Tag("${map}"))
+		assert.Nil(t, err)
+		assert.Equal(t, m, map[string][]int{
+			"a": {1, 2},
+			"b": {3, 4, 5},
+		})
+	})
+
+	t.Run("map", func(t *testing.T) {
+		var m map[string]map[string]int
+		err := Map(map[string]interface{}{
+			"map": map[string]interface{}{
+				"a": map[string]int{"x": 1},
+				"b": map[string]int{"y": 2, "z": 3},
+			},
+		}).Bind(&m, Tag("${map}"))
+		assert.Nil(t, err)
+		assert.Equal(t, m, map[string]map[string]int{
+			"a": {"x": 1},
+			"b": {"y": 2, "z": 3},
+		})
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		type server struct {
+			Host  string `value:"${host}"`
+			Ports []int  `value:"${ports:=80}"`
+			Auth  struct {
+				User string `value:"${user:=}"`
+			} `value:"${auth}"`
+		}
+		var m map[string]server
+		err := Map(map[string]interface{}{
+			"servers": map[string]interface{}{
+				"a": map[string]interface{}{
+					"host":  "a.com",
+					"ports": []int{80, 443},
+					"auth":  map[string]interface{}{"user": "root"},
+				},
+				"b": map[string]interface{}{
+					"host": "b.com",
+				},
+			},
+		}).Bind(&m, Tag("${servers}"))
+		assert.Nil(t, err)
+		assert.Equal(t, len(m), 2)
+		assert.Equal(t, m["a"].Host, "a.com")
+		assert.Equal(t, m["a"].Ports, []int{80, 443})
+		assert.Equal(t, m["a"].Auth.User, "root")
+		assert.Equal(t, m["b"].Host, "b.com")
+		assert.Equal(t, m["b"].Ports, []int{80})
+		assert.Equal(t, m["b"].Auth.User, "")
+	})
 }
 
 func TestBind_Validate(t *testing.T) {
@@ -1055,6 +1264,80 @@ func TestBind_Validate(t *testing.T) {
 		assert.Equal(t, v.Uint, uint(3))
 	})
 
+	t.Run("min max", func(t *testing.T) {
+		var v struct {
+			Port int `value:"${port}" min:"1" max:"65535"`
+		}
+
+		err := Map(map[string]interface{}{"port": 0}).Bind(&v)
+		assert.Error(t, err, "validate .*Port error: validate failed on min \"1\" for value 0")
+
+		err = Map(map[string]interface{}{"port": 65536}).Bind(&v)
+		assert.Error(t, err, "validate .*Port error: validate failed on max \"65535\" for value 65536")
+
+		err = Map(map[string]interface{}{"port": 8080}).Bind(&v)
+		assert.Nil(t, err)
+		assert.Equal(t, v.Port, 8080)
+	})
+
+	t.Run("dive", func(t *testing.T) {
+		var v struct {
+			Ports []int          `value:"${ports}" dive:"$>0"`
+			Nodes map[string]int `value:"${nodes}" dive:"min:\"1\""`
+		}
+
+		err := Map(map[string]interface{}{
+			"ports": []int{80, 0, 443},
+			"nodes": map[string]int{"a": 1},
+		}).Bind(&v)
+		assert.Error(t, err, "validate .*Ports error: dive \\[1\\] error: validate failed on \"\\$>0\" for value 0")
+
+		err = Map(map[string]interface{}{
+			"ports": []int{80, 443},
+			"nodes": map[string]int{"a": 1, "b": 2},
+		}).Bind(&v)
+		assert.Nil(t, err)
+		assert.Equal(t, v.Ports, []int{80, 443})
+		assert.Equal(t, v.Nodes, map[string]int{"a": 1, "b": 2})
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		var v struct {
+			Start int `value:"${start}"`
+			End   int `value:"${end}" expr:"$>fields.Start"`
+		}
+
+		err := Map(map[string]interface{}{
+			"start": 10,
+			"end":   5,
+		}).Bind(&v)
+		assert.Error(t, err, "validate .*End error: validate failed on \"\\$>fields.Start\" for value 5")
+
+		err = Map(map[string]interface{}{
+			"start": 10,
+			"end":   20,
+		}).Bind(&v)
+		assert.Nil(t, err)
+		assert.Equal(t, v.End, 20)
+	})
+
+	t.Run("scale", func(t *testing.T) {
+		var v struct {
+			Price float64 `value:"${price}" scale:"2"`
+		}
+
+		err := Map(map[string]interface{}{
+			"price": 9.999,
+		}).Bind(&v)
+		assert.Error(t, err, "bind .* error: validate .*Price error: validate failed on scale \"2\" for value 9.999")
+
+		err = Map(map[string]interface{}{
+			"price": 9.99,
+		}).Bind(&v)
+		assert.Nil(t, err)
+		assert.Equal(t, v.Price, 9.99)
+	})
+
 	t.Run("int", func(t *testing.T) {
 		var v struct {
 			Int int `value:"${int:=2}" expr:"$>=3"`
@@ -1131,6 +1414,643 @@ func TestBind_Validate(t *testing.T) {
 	})
 }
 
+func TestBind_Required(t *testing.T) {
+
+	var s struct {
+		Name string `value:"${name}" required:"true"`
+		Port int    `value:"${port:=}" required:"true"`
+	}
+
+	err := Map(map[string]interface{}{
+		"port": 8080,
+	}).Bind(&s)
+	assert.Error(t, err, "bind .* error: required property \"name\" is empty")
+
+	err = Map(map[string]interface{}{
+		"name": "",
+		"port": 8080,
+	}).Bind(&s)
+	assert.Error(t, err, "bind .* error: required property \"name\" is empty")
+
+	err = Map(map[string]interface{}{
+		"name": "go-spring",
+	}).Bind(&s)
+	assert.Error(t, err, "bind .* error: required property \"port\" is empty")
+
+	err = Map(map[string]interface{}{
+		"name": "go-spring",
+		"port": 8080,
+	}).Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Name, "go-spring")
+	assert.Equal(t, s.Port, 8080)
+
+	// defaults don't satisfy required, but candidates and aliases do.
+	var d struct {
+		Port int `value:"${port|server.port:=8080}" required:"true" alias:"http.port"`
+	}
+	err = Map(nil).Bind(&d)
+	assert.Error(t, err, "bind .*Port error: required property \"port\" is empty")
+
+	for _, key := range []string{"port", "server.port", "http.port"} {
+		err = Map(map[string]interface{}{
+			key: 9090,
+		}).Bind(&d)
+		assert.Nil(t, err)
+		assert.Equal(t, d.Port, 9090)
+	}
+
+	// maps, slices and structs are required too.
+	var c struct {
+		Hosts []string          `value:"${hosts:=}" required:"true"`
+		Attrs map[string]string `value:"${attrs:=}" required:"true"`
+		DB    struct {
+			URL string `value:"${url:=}"`
+		} `value:"${db}" required:"true"`
+	}
+	err = Map(map[string]interface{}{
+		"attrs.a": "b",
+		"db.url":  "mysql://localhost",
+	}).Bind(&c)
+	assert.Error(t, err, "bind .*Hosts error: required property \"hosts\" is empty")
+
+	err = Map(map[string]interface{}{
+		"hosts":  "",
+		"attrs":  map[string]string{"a": "b"},
+		"db.url": "mysql://localhost",
+	}).Bind(&c)
+	assert.Error(t, err, "bind .*Hosts error: required property \"hosts\" is empty")
+
+	err = Map(map[string]interface{}{
+		"hosts":  "a.com",
+		"db.url": "mysql://localhost",
+	}).Bind(&c)
+	assert.Error(t, err, "bind .*Attrs error: required property \"attrs\" is empty")
+
+	err = Map(map[string]interface{}{
+		"hosts":   []string{"a.com"},
+		"attrs.a": "b",
+	}).Bind(&c)
+	assert.Error(t, err, "bind .*DB error: required property \"db\" is empty")
+
+	err = Map(map[string]interface{}{
+		"hosts":   "a.com,b.com",
+		"attrs.a": "b",
+		"db.url":  "mysql://localhost",
+	}).Bind(&c)
+	assert.Nil(t, err)
+	assert.Equal(t, c.Hosts, []string{"a.com", "b.com"})
+	assert.Equal(t, c.Attrs, map[string]string{"a": "b"})
+	assert.Equal(t, c.DB.URL, "mysql://localhost")
+}
+
+func TestBind_RequiredIf(t *testing.T) {
+
+	type TLS struct {
+		Enabled bool   `value:"${enabled:=false}"`
+		Cert    string `value:"${cert:=}" required:"fields.Enabled"`
+	}
+
+	var s struct {
+		TLS TLS `value:"${tls}"`
+	}
+
+	err := Map(nil).Bind(&s)
+	assert.Nil(t, err)
+
+	err = Map(map[string]interface{}{
+		"tls.enabled": true,
+	}).Bind(&s)
+	assert.Error(t, err, "bind .*TLS.Cert error: required property \"tls.cert\" is empty")
+
+	err = Map(map[string]interface{}{
+		"tls.enabled": true,
+		"tls.cert":    "server.pem",
+	}).Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.TLS, TLS{Enabled: true, Cert: "server.pem"})
+
+	var v struct {
+		Cert string `value:"${cert:=}" required:"fields.Missing"`
+	}
+	err = Map(nil).Bind(&v)
+	assert.Error(t, err, "eval \"fields.Missing\" ")
+}
+
+func TestBind_Interface(t *testing.T) {
+
+	p := Map(map[string]interface{}{
+		"bool":   "true",
+		"int":    "8080",
+		"float":  "0.5",
+		"string": "localhost",
+		"nan":    "NaN",
+		"list":   []interface{}{"a", 1},
+		"map": map[string]interface{}{
+			"x": "false",
+			"y": map[string]interface{}{"z": "-1"},
+		},
+	})
+
+	t.Run("values", func(t *testing.T) {
+		for key, expect := range map[string]interface{}{
+			"bool":   true,
+			"int":    int64(8080),
+			"float":  0.5,
+			"string": "localhost",
+			"nan":    "NaN",
+		} {
+			var v interface{}
+			err := p.Bind(&v, Key(key))
+			assert.Nil(t, err)
+			assert.Equal(t, v, expect)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		var s struct {
+			List interface{}            `value:"${list}"`
+			Map  map[string]interface{} `value:"${map}"`
+			Any  interface{}            `value:"${map}"`
+			Def  interface{}            `value:"${def:=3}"`
+		}
+		err := p.Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.List, []interface{}{"a", int64(1)})
+		m := map[string]interface{}{
+			"x": false,
+			"y": map[string]interface{}{"z": int64(-1)},
+		}
+		assert.Equal(t, s.Map, m)
+		assert.Equal(t, s.Any, m)
+		assert.Equal(t, s.Def, int64(3))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var s struct {
+			Any interface{} `value:"${none}"`
+		}
+		err := p.Bind(&s)
+		assert.Error(t, err, "bind .*Any error: property \"none\": not exist")
+	})
+}
+
+func TestBind_Transform(t *testing.T) {
+
+	var s struct {
+		Name  string `value:"${name}" transform:"trim"`
+		Level string `value:"${level}" transform:"lower"`
+		Mode  string `value:"${mode}" transform:"trim,lower" expr:"$ == \"debug\""`
+		Raw   string `value:"${raw}"`
+	}
+
+	err := Map(map[string]interface{}{
+		"name":  "  app ",
+		"level": "INFO",
+		"mode":  " Debug\t",
+		"raw":   " X ",
+	}).Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Name, "app")
+	assert.Equal(t, s.Level, "info")
+	assert.Equal(t, s.Mode, "debug")
+	assert.Equal(t, s.Raw, " X ")
+
+	var v struct {
+		Name string `value:"${name}" transform:"title"`
+	}
+	err = Map(map[string]interface{}{"name": "app"}).Bind(&v)
+	assert.Error(t, err, "bind .*Name error: unsupported transform \"title\"")
+}
+
+func TestBind_Candidates(t *testing.T) {
+
+	type Server struct {
+		Port int `value:"${server.port|http.port|port:=8080}"`
+	}
+
+	var s Server
+	err := Map(map[string]interface{}{
+		"http.port": 9090,
+		"port":      7070,
+	}).Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Port, 9090)
+
+	err = Map(nil).Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Port, 8080)
+
+	var v struct {
+		Server struct {
+			Port int `value:"${port|http.port}"`
+		} `value:"${s}"`
+	}
+	err = Map(map[string]interface{}{
+		"s.http.port": 9090,
+	}).Bind(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, v.Server.Port, 9090)
+
+	err = Map(nil).Bind(&v)
+	assert.Error(t, err, "property \"s.port\": not exist")
+}
+
+func TestBind_ValueResolver(t *testing.T) {
+
+	r := Snapshot()
+	t.Cleanup(func() { Restore(r) })
+
+	secrets := map[string]string{"db.password": "s3cr3t"}
+	RegisterValueResolver("secret", func(key string) (string, error) {
+		if v, ok := secrets[key]; ok {
+			return v, nil
+		}
+		return "", fmt.Errorf("secret %q not found", key)
+	})
+
+	var db struct {
+		User     string `value:"${user}"`
+		Password string `value:"${secret:db.password}"`
+		Token    string `value:"${secret:db.token}"`
+	}
+
+	p := Map(map[string]interface{}{
+		"db": map[string]interface{}{
+			"user":     "root",
+			"password": "plain",
+		},
+	})
+
+	err := p.Bind(&db, Key("db"))
+	assert.Error(t, err, "bind .*Token error: secret \"db.token\" not found")
+
+	secrets["db.token"] = "t0ken"
+	err = p.Bind(&db, Key("db"))
+	assert.Nil(t, err)
+	assert.Equal(t, db.User, "root")
+	assert.Equal(t, db.Password, "s3cr3t")
+	assert.Equal(t, db.Token, "t0ken")
+
+	s, err := p.Resolve("${db.user}:${secret:db.password}")
+	assert.Nil(t, err)
+	assert.Equal(t, s, "root:s3cr3t")
+}
+
+func TestBind_StrictStruct(t *testing.T) {
+
+	SetStrictStruct(true)
+	defer SetStrictStruct(false)
+
+	type Base struct {
+		Name string `value:"${name}"`
+	}
+
+	p := Map(map[string]interface{}{
+		"name": "app",
+		"port": 8080,
+		"Host": "localhost",
+	})
+
+	var ok struct {
+		Base
+		Port  int  `value:"${port}"`
+		Debug bool `value:"-"`
+	}
+	err := p.Bind(&ok)
+	assert.Nil(t, err)
+	assert.Equal(t, ok.Name, "app")
+	assert.Equal(t, ok.Port, 8080)
+
+	var missing struct {
+		Port int `value:"${port}"`
+		Host string
+	}
+	err = p.Bind(&missing)
+	assert.Error(t, err, "bind .* error: field Host has neither 'value' tag nor `value:\"-\"`")
+
+	SetStrictStruct(false)
+	err = p.Bind(&missing)
+	assert.Nil(t, err)
+	assert.Equal(t, missing.Host, "localhost")
+}
+
+func TestBind_Bytes(t *testing.T) {
+
+	var v struct {
+		Key   []byte `value:"${key:=}" encoding:"base64"`
+		Token []byte `value:"${token:=}" encoding:"hex"`
+		Raw   []byte `value:"${raw:=}"`
+	}
+
+	err := Map(map[string]interface{}{
+		"key":   "aGVsbG8=",
+		"token": "776f726c64",
+		"raw":   []int{1, 2, 3},
+	}).Bind(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, v.Key, []byte("hello"))
+	assert.Equal(t, v.Token, []byte("world"))
+	assert.Equal(t, v.Raw, []byte{1, 2, 3})
+
+	// "1234" is valid base64, but without the tag it's a list of bytes.
+	var tagged struct {
+		Bytes []byte `value:"${bytes}" encoding:"base64"`
+	}
+	err = Map(map[string]interface{}{
+		"bytes": "1234",
+	}).Bind(&tagged)
+	assert.Nil(t, err)
+	assert.Equal(t, tagged.Bytes, []byte{0xd7, 0x6d, 0xf8})
+
+	var untagged struct {
+		Bytes   []byte `value:"${bytes}"`
+		Default []byte `value:"${default:=1,2}"`
+	}
+	err = Map(map[string]interface{}{
+		"bytes": "1,2,3,4",
+	}).Bind(&untagged)
+	assert.Nil(t, err)
+	assert.Equal(t, untagged.Bytes, []byte{1, 2, 3, 4})
+	assert.Equal(t, untagged.Default, []byte{1, 2})
+
+	// one element of the list, not the three decoded bytes.
+	err = Map(map[string]interface{}{
+		"bytes": "1234",
+	}).Bind(&untagged)
+	assert.Nil(t, err)
+	assert.Equal(t, len(untagged.Bytes), 1)
+	assert.NotEqual(t, untagged.Bytes, tagged.Bytes)
+
+	err = Map(map[string]interface{}{
+		"key": "not base64!",
+	}).Bind(&v)
+	assert.Error(t, err, "bind .*Key error: illegal base64 data at input byte 3")
+
+	err = Map(map[string]interface{}{
+		"token": "xyz",
+	}).Bind(&v)
+	assert.Error(t, err, "bind .*Token error: encoding/hex: invalid byte")
+
+	var b struct {
+		Key []byte `value:"${key}" encoding:"base32"`
+	}
+	err = Map(map[string]interface{}{
+		"key": "abc",
+	}).Bind(&b)
+	assert.Error(t, err, "bind .*Key error: unsupported encoding \"base32\"")
+}
+
+// version implements encoding.TextUnmarshaler.
+type version struct {
+	Major, Minor int
+}
+
+func (v *version) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "v%d.%d", &v.Major, &v.Minor)
+	return err
+}
+
+func TestBind_TextUnmarshaler(t *testing.T) {
+
+	var v struct {
+		Version  version   `value:"${version}" empty:""`
+		Versions []version `value:"${versions:=v1.0,v1.1}"`
+		Level    slog.Level
+	}
+
+	empty.reset()
+	defer empty.reset()
+
+	err := Map(map[string]interface{}{
+		"version": "v1.2",
+		"Level":   "WARN",
+	}).Bind(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, empty.count, 1)
+	assert.Equal(t, v.Version, version{1, 2})
+	assert.Equal(t, v.Versions, []version{{1, 0}, {1, 1}})
+	assert.Equal(t, v.Level, slog.LevelWarn)
+
+	err = Map(map[string]interface{}{
+		"version": "1.2",
+		"Level":   "WARN",
+	}).Bind(&v)
+	assert.Error(t, err, "bind .*Version error: input does not match format")
+}
+
+func TestBind_EmptyDefault(t *testing.T) {
+
+	testcases := []struct {
+		tag    string
+		str    string
+		strErr string
+		num    int
+		numErr string
+	}{
+		{
+			tag:    "${k}",
+			strErr: "bind string error: property \"k\": not exist",
+			numErr: "bind int error: property \"k\": not exist",
+		},
+		{
+			tag: "${k:=}",
+			str: "",
+			num: 0,
+		},
+		{
+			tag: "${k:=3}",
+			str: "3",
+			num: 3,
+		},
+	}
+
+	for _, c := range testcases {
+		p := Map(nil)
+
+		str := "x"
+		err := p.Bind(&str, Tag(c.tag))
+		if c.strErr != "" {
+			assert.Error(t, err, c.strErr)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, str, c.str)
+		}
+
+		num := -1
+		err = p.Bind(&num, Tag(c.tag))
+		if c.numErr != "" {
+			assert.Error(t, err, c.numErr)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, num, c.num)
+		}
+	}
+
+	var v struct {
+		Bool  bool           `value:"${bool:=}"`
+		Slice []int          `value:"${slice:=}"`
+		Map   map[string]int `value:"${map:=}"`
+	}
+	err := Map(nil).Bind(&v)
+	assert.Nil(t, err)
+	assert.False(t, v.Bool)
+	assert.Equal(t, len(v.Slice), 0)
+	assert.Equal(t, len(v.Map), 0)
+
+	// a present empty value isn't a default.
+	var num int
+	err = Map(map[string]interface{}{"k": ""}).Bind(&num, Tag("${k:=}"))
+	assert.Error(t, err, "bind int error: strconv.ParseInt: parsing \"\": invalid syntax")
+}
+
+func TestBind_Alias(t *testing.T) {
+
+	var buf bytes.Buffer
+	fn := deprecationLogger
+	t.Cleanup(func() { deprecationLogger = fn })
+	deprecationLogger = func(p *Properties) *log.Logger {
+		return slog.New(slog.NewTextHandler(&buf, nil))
+	}
+
+	type Server struct {
+		Port int `value:"${port}" alias:"listen.port, http.port"`
+	}
+
+	var s struct {
+		Server Server `value:"${server}"`
+	}
+
+	t.Run("alias", func(t *testing.T) {
+		buf.Reset()
+		err := Map(map[string]interface{}{
+			"server.http.port": 8080,
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.Server.Port, 8080)
+		assert.String(t, buf.String()).Contains(`level=WARN msg="property \"server.http.port\" is deprecated, use \"server.port\" instead"`)
+	})
+
+	t.Run("new key takes precedence", func(t *testing.T) {
+		buf.Reset()
+		err := Map(map[string]interface{}{
+			"server.port":      9090,
+			"server.http.port": 8080,
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.Server.Port, 9090)
+		assert.Equal(t, buf.String(), "")
+	})
+
+	t.Run("not exist", func(t *testing.T) {
+		err := Map(nil).Bind(&s)
+		assert.Error(t, err, "bind .* error: property \"server.port\": not exist")
+	})
+}
+
+type planPoint struct {
+	X, Y int
+}
+
+func TestBind_StructPlan(t *testing.T) {
+
+	r := Snapshot()
+	t.Cleanup(func() { Restore(r) })
+
+	type Shape struct {
+		Name  string `value:"${name}"`
+		Point *planPoint
+	}
+
+	p := Map(map[string]interface{}{
+		"name":  "dot",
+		"Point": "1,2",
+	})
+
+	var s Shape
+	err := p.Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s, Shape{Name: "dot"})
+
+	// the cached plan skips the pointer field, it must be rebuilt after a
+	// converter for the pointer type is registered.
+	RegisterConverter(func(s string) (*planPoint, error) {
+		ss := strings.Split(s, ",")
+		x, _ := strconv.Atoi(ss[0])
+		y, _ := strconv.Atoi(ss[1])
+		return &planPoint{X: x, Y: y}, nil
+	})
+
+	s = Shape{}
+	err = p.Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s, Shape{Name: "dot", Point: &planPoint{X: 1, Y: 2}})
+}
+
+func BenchmarkBind_Struct(b *testing.B) {
+
+	type Server struct {
+		Host    string        `value:"${host:=localhost}"`
+		Port    int           `value:"${port:=8080}"`
+		Timeout time.Duration `value:"${timeout:=5s}"`
+		Tags    []string      `value:"${tags:=a,b,c}"`
+	}
+
+	var s struct {
+		Server  Server  `value:"${server}"`
+		Backup  Server  `value:"${backup}"`
+		Enabled bool    `value:"${enabled:=true}"`
+		Ratio   float64 `value:"${ratio:=0.5}"`
+	}
+
+	p := New()
+
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = p.Bind(&s)
+		}
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			resetStructPlans()
+			_ = p.Bind(&s)
+		}
+	})
+}
+
+func TestBind_JSONTagAsKey(t *testing.T) {
+
+	type Server struct {
+		Host    string `json:"host_name,omitempty"`
+		Port    int    `json:"port"`
+		Timeout int
+		Secret  string `json:"-"`
+	}
+
+	var s struct {
+		Server Server `json:"server"`
+	}
+
+	p := Map(map[string]interface{}{
+		"server": map[string]interface{}{
+			"host_name": "localhost",
+			"port":      8080,
+			"Timeout":   5,
+		},
+	})
+
+	err := p.Bind(&s)
+	assert.Error(t, err, "bind .* error: property \"Server.Host\": not exist")
+
+	SetUseJSONTagAsKey(true)
+	defer SetUseJSONTagAsKey(false)
+
+	err = p.Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Server, Server{Host: "localhost", Port: 8080, Timeout: 5})
+}
+
 func TestBind_StructValue(t *testing.T) {
 
 	t.Run("unexported", func(t *testing.T) {
@@ -1151,6 +2071,53 @@ func TestBind_StructValue(t *testing.T) {
 	})
 }
 
+func TestFilterChain(t *testing.T) {
+
+	var s struct {
+		Host string `value:"${host:=localhost}"`
+		Port int    `value:"${port:=8080}"`
+		Name string `value:"${name:=app}"`
+	}
+
+	var calls []string
+	hostFilter := func(i interface{}, param BindParam) (bool, error) {
+		calls = append(calls, "host:"+param.Key)
+		if param.Key == "host" {
+			reflect.ValueOf(i).Elem().SetString("example.com")
+			return true, nil
+		}
+		return false, nil
+	}
+	portFilter := func(i interface{}, param BindParam) (bool, error) {
+		calls = append(calls, "port:"+param.Key)
+		if param.Key == "port" {
+			reflect.ValueOf(i).Elem().SetInt(9090)
+			return true, nil
+		}
+		return false, nil
+	}
+
+	v := reflect.ValueOf(&s).Elem()
+	param := BindParam{Path: v.Type().String()}
+	err := param.BindTag("${ROOT}", "")
+	assert.Nil(t, err)
+
+	err = BindValue(Map(nil), v, v.Type(), param, FilterChain(hostFilter, nil, portFilter))
+	assert.Nil(t, err)
+	assert.Equal(t, s.Host, "example.com")
+	assert.Equal(t, s.Port, 9090)
+	assert.Equal(t, s.Name, "app")
+	assert.Equal(t, calls, []string{"host:host", "host:port", "port:port", "host:name", "port:name"})
+
+	errFilter := func(i interface{}, param BindParam) (bool, error) {
+		return false, errors.New("this is an error")
+	}
+	calls = nil
+	err = BindValue(Map(nil), v, v.Type(), param, FilterChain(errFilter, hostFilter))
+	assert.Error(t, err, "bind .* error: this is an error")
+	assert.Nil(t, calls)
+}
+
 func TestBind_StructFilter(t *testing.T) {
 
 	t.Run("error", func(t *testing.T) {
@@ -1250,6 +2217,39 @@ func TestBind_Splitter(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, s, []int{1, 2, 3})
 	})
+
+	t.Run("default", func(t *testing.T) {
+		RegisterSplitter("splitter", func(s string) ([]string, error) {
+			return strings.Split(s, ";"), nil
+		})
+		defer RemoveSplitter("splitter")
+		p := Map(map[string]interface{}{
+			"s": "1 2  3",
+			"t": "4;5",
+		})
+		p.SetDefaultSplitter(func(s string) ([]string, error) {
+			return strings.Fields(s), nil
+		})
+		var s struct {
+			S []int `value:"${s}"`
+			T []int `value:"${t}||splitter"`
+			D []int `value:"${d:=6 7}"`
+		}
+		err := p.Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.S, []int{1, 2, 3})
+		assert.Equal(t, s.T, []int{4, 5})
+		assert.Equal(t, s.D, []int{6, 7})
+
+		var c []int
+		err = p.Copy().Bind(&c, Key("s"))
+		assert.Nil(t, err)
+		assert.Equal(t, c, []int{1, 2, 3})
+
+		p.SetDefaultSplitter(nil)
+		err = p.Bind(&c, Key("s"))
+		assert.Error(t, err, "strconv.ParseInt: parsing \"1 2  3\": invalid syntax")
+	})
 }
 
 func TestBind_Converter(t *testing.T) {
@@ -1276,6 +2276,134 @@ func TestBind_Converter(t *testing.T) {
 	})
 }
 
+func TestBind_NamedConverter(t *testing.T) {
+
+	r := Snapshot()
+	t.Cleanup(func() { Restore(r) })
+
+	RegisterNamedConverter("rfc3339", func(s string) (time.Time, error) {
+		return time.Parse(time.RFC3339, s)
+	})
+	RegisterNamedConverter("date", func(s string) (time.Time, error) {
+		return time.Parse("2006/01/02", s)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		var s struct {
+			Created time.Time   `value:"${created}" converter:"rfc3339"`
+			Expired time.Time   `value:"${expired}" converter:"date"`
+			Holiday []time.Time `value:"${holiday}" converter:"date"`
+		}
+		err := Map(map[string]interface{}{
+			"created": "2023-06-17T13:20:15Z",
+			"expired": "2024/01/02",
+			"holiday": "2024/05/01,2024/10/01",
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.Created, time.Date(2023, 6, 17, 13, 20, 15, 0, time.UTC))
+		assert.Equal(t, s.Expired, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, s.Holiday, []time.Time{
+			time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+		})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var s struct {
+			Expired time.Time `value:"${expired}" converter:"date"`
+		}
+		err := Map(map[string]interface{}{
+			"expired": "2023-06-17T13:20:15Z",
+		}).Bind(&s)
+		assert.Error(t, err, "bind .* error: parsing time \"2023-06-17T13:20:15Z\"")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		var s struct {
+			Expired time.Time `value:"${expired}" converter:"unknown"`
+		}
+		err := Map(map[string]interface{}{
+			"expired": "2024/01/02",
+		}).Bind(&s)
+		assert.Error(t, err, "bind .* error: converter \"unknown\" not found")
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		var s struct {
+			Expired int `value:"${expired}" converter:"date"`
+		}
+		err := Map(map[string]interface{}{
+			"expired": "2024/01/02",
+		}).Bind(&s)
+		assert.Error(t, err, "bind .* error: converter \"date\" can't convert to int")
+	})
+}
+
+func TestBind_StdConverter(t *testing.T) {
+
+	t.Run("ip", func(t *testing.T) {
+		var s struct {
+			IP net.IP `value:"${ip}"`
+		}
+		err := Map(map[string]interface{}{
+			"ip": "192.168.1.1",
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.IP, net.ParseIP("192.168.1.1"))
+
+		err = Map(map[string]interface{}{
+			"ip": "192.168.1",
+		}).Bind(&s)
+		assert.Error(t, err, "bind .* error: invalid IP address \"192.168.1\"")
+	})
+
+	t.Run("ips", func(t *testing.T) {
+		var s struct {
+			IPs []net.IP `value:"${ips}"`
+		}
+		err := Map(map[string]interface{}{
+			"ips": "127.0.0.1, ::1",
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.IPs, []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")})
+	})
+
+	t.Run("cidr", func(t *testing.T) {
+		var s struct {
+			Net *net.IPNet `value:"${net}"`
+		}
+		err := Map(map[string]interface{}{
+			"net": "10.0.0.0/8",
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.Net.String(), "10.0.0.0/8")
+
+		err = Map(map[string]interface{}{
+			"net": "10.0.0.0/33",
+		}).Bind(&s)
+		assert.Error(t, err, "bind .* error: invalid CIDR address: 10.0.0.0/33")
+	})
+
+	t.Run("url", func(t *testing.T) {
+		var s struct {
+			URL url.URL `value:"${url}"`
+		}
+		err := Map(map[string]interface{}{
+			"url": "https://user@example.com:8080/path?q=1#frag",
+		}).Bind(&s)
+		assert.Nil(t, err)
+		assert.Equal(t, s.URL.Scheme, "https")
+		assert.Equal(t, s.URL.Host, "example.com:8080")
+		assert.Equal(t, s.URL.Path, "/path")
+		assert.Equal(t, s.URL.RawQuery, "q=1")
+
+		err = Map(map[string]interface{}{
+			"url": "http://[::1",
+		}).Bind(&s)
+		assert.Error(t, err, "bind .* error: parse \"http://\\[::1\": missing ']' in host")
+	})
+}
+
 func TestBind_ReflectValue(t *testing.T) {
 
 	assert.Panic(t, func() {
@@ -1305,3 +2433,158 @@ func TestBind_ReflectValue(t *testing.T) {
 		assert.Equal(t, i, 1)
 	})
 }
+
+// byteSize is a size in bytes bound from strings like "512B", "1KB" or "2MB".
+type byteSize uint64
+
+func parseByteSize(s string) (byteSize, error) {
+	units := []struct {
+		suffix string
+		size   byteSize
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			n, err := strconv.ParseUint(strings.TrimSuffix(s, u.suffix), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return byteSize(n) * u.size, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid byte size %q", s)
+}
+
+func TestBind_UnitSlice(t *testing.T) {
+
+	r := Snapshot()
+	t.Cleanup(func() { Restore(r) })
+
+	RegisterConverter(parseByteSize)
+
+	var s struct {
+		Timeouts []time.Duration `value:"${timeouts}"`
+		Defaults []time.Duration `value:"${defaults:=1s, 1m}"`
+		Sizes    []byteSize      `value:"${sizes}"`
+	}
+
+	p := Map(map[string]interface{}{
+		"timeouts": "10s,20s,1m30s",
+		"sizes":    []string{"512B", "1KB", "2MB"},
+	})
+	err := p.Bind(&s)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Timeouts, []time.Duration{10 * time.Second, 20 * time.Second, 90 * time.Second})
+	assert.Equal(t, s.Defaults, []time.Duration{time.Second, time.Minute})
+	assert.Equal(t, s.Sizes, []byteSize{512, 1 << 10, 2 << 20})
+
+	err = p.Set("sizes[1]", "1K")
+	assert.Nil(t, err)
+	err = p.Bind(&s)
+	assert.Error(t, err, "Sizes\\[1\\] error: invalid byte size \"1K\"")
+}
+
+func TestBind_ZeroOnMissing(t *testing.T) {
+
+	type Server struct {
+		Host string `value:"${host}"`
+		Port int    `value:"${port}"`
+	}
+
+	type Config struct {
+		Port    int      `value:"${port}"`
+		Name    string   `value:"${name:=app}"`
+		Hosts   []string `value:"${hosts}"`
+		Servers []Server `value:"${servers}"`
+		Server  Server   `value:"${server}"`
+	}
+
+	p := Map(map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a.com"},
+			map[string]interface{}{"port": 81},
+		},
+	})
+
+	var c Config
+	err := p.Bind(&c)
+	assert.Error(t, err, "property \"port\": not exist")
+
+	err = p.Bind(&c, ZeroOnMissing())
+	assert.Nil(t, err)
+	assert.Equal(t, c, Config{
+		Name:    "app",
+		Servers: []Server{{Host: "a.com"}, {Port: 81}},
+	})
+
+	var port int
+	err = p.Bind(&port, Key("server.port"), ZeroOnMissing())
+	assert.Nil(t, err)
+	assert.Equal(t, port, 0)
+
+	err = p.Set("port", "abc")
+	assert.Nil(t, err)
+	err = p.Bind(&c, ZeroOnMissing())
+	assert.Error(t, err, "bind Config.Port error: strconv.ParseInt: parsing \"abc\": invalid syntax")
+
+	var s struct {
+		Port int `value:"${port}" required:"true"`
+	}
+	err = Map(nil).Bind(&s, ZeroOnMissing())
+	assert.Error(t, err, "required property \"port\" is empty")
+
+	var v struct {
+		Version version `value:"${version}"`
+	}
+	err = Map(nil).Bind(&v)
+	assert.Error(t, err, "bind .*Version error: property \"version\": not exist")
+	err = Map(nil).Bind(&v, ZeroOnMissing())
+	assert.Nil(t, err)
+	assert.Equal(t, v.Version, version{})
+}
+
+func TestBind_Lenient(t *testing.T) {
+
+	type Config struct {
+		Enabled bool    `value:"${enabled}"`
+		Debug   bool    `value:"${debug}"`
+		Size    int     `value:"${size}"`
+		Limit   uint    `value:"${limit}"`
+		Ports   []int   `value:"${ports}"`
+		Flags   []bool  `value:"${flags}"`
+		Ratio   float64 `value:"${ratio:=0.5}"`
+	}
+
+	p := Map(map[string]interface{}{
+		"enabled": "yes",
+		"debug":   "1",
+		"size":    "1,000",
+		"limit":   "1_000",
+		"ports":   []string{"8,080", "9090"},
+		"flags":   []string{"On", "OFF"},
+	})
+
+	var c Config
+	err := p.Bind(&c, Lenient())
+	assert.Nil(t, err)
+	assert.Equal(t, c, Config{
+		Enabled: true,
+		Debug:   true,
+		Size:    1000,
+		Limit:   1000,
+		Ports:   []int{8080, 9090},
+		Flags:   []bool{true, false},
+		Ratio:   0.5,
+	})
+
+	err = p.Bind(&c)
+	assert.Error(t, err, "bind Config.Enabled error: strconv.ParseBool: parsing \"yes\": invalid syntax")
+
+	var size int
+	err = p.Bind(&size, Key("size"))
+	assert.Error(t, err, "strconv.ParseInt: parsing \"1,000\": invalid syntax")
+
+	var limit uint
+	err = p.Bind(&limit, Key("limit"))
+	assert.Nil(t, err)
+	assert.Equal(t, limit, uint(1000))
+}
@@ -23,6 +23,9 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"maps"
+	"net"
+	"net/url"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -42,15 +45,24 @@ type Splitter func(string) ([]string, error)
 // Reader parses []byte into nested map[string]interface{}.
 type Reader func(b []byte) (map[string]interface{}, error)
 
+// ValueResolver resolves the value of a key from outside the Properties, such
+// as a secret provider.
+type ValueResolver func(key string) (string, error)
+
 var (
-	readers    = map[string]Reader{}
-	splitters  = map[string]Splitter{}
-	converters = map[reflect.Type]utils.Converter{}
+	readers         = map[string]Reader{}
+	splitters       = map[string]Splitter{}
+	converters      = map[reflect.Type]utils.Converter{}
+	namedConverters = map[string]utils.Converter{}
+	valueResolvers  = map[string]ValueResolver{}
+	useJSONTagAsKey = false
+	strictStruct    = false
 )
 
 func init() {
 
 	RegisterReader(prop.Read, ".properties")
+	RegisterReader(prop.ReadEnv, ".env")
 	RegisterReader(yaml.Read, ".yaml", ".yml")
 	RegisterReader(toml.Read, ".toml", ".tml")
 
@@ -72,6 +84,49 @@ func init() {
 	RegisterConverter(func(s string) (time.Duration, error) {
 		return cast.ToDurationE(s)
 	})
+
+	// converts string into net.IP, both IPv4 and IPv6 textual forms are
+	// accepted.
+	RegisterConverter(func(s string) (net.IP, error) {
+		ip := net.ParseIP(strings.TrimSpace(s))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", s)
+		}
+		return ip, nil
+	})
+
+	// converts string into *net.IPNet, the string should be a CIDR notation
+	// IP address and prefix length, like "192.0.2.0/24".
+	RegisterConverter(func(s string) (*net.IPNet, error) {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		return ipNet, nil
+	})
+
+	// converts string into url.URL.
+	RegisterConverter(func(s string) (url.URL, error) {
+		u, err := url.Parse(strings.TrimSpace(s))
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *u, nil
+	})
+}
+
+// SetUseJSONTagAsKey sets whether to use the name of 'json' tag as the key of a
+// struct field that has no 'value' tag, instead of the field name.
+func SetUseJSONTagAsKey(enable bool) {
+	useJSONTagAsKey = enable
+	resetStructPlans()
+}
+
+// SetStrictStruct sets whether binding a struct requires each exported value
+// type field either has a 'value' tag, or is an anonymous embed, or is ignored
+// explicitly by `value:"-"`, it helps to find fields forgetting their tags.
+func SetStrictStruct(enable bool) {
+	strictStruct = enable
 }
 
 // RegisterReader registers its Reader for some kind of file extension.
@@ -92,13 +147,87 @@ func RemoveSplitter(name string) {
 }
 
 // RegisterConverter registers its converter for non-primitive type such as
-// time.Time, time.Duration, or other user-defined value type.
+// time.Time, time.Duration, or other user-defined value type. It panics when
+// fn isn't a converter.
 func RegisterConverter(fn utils.Converter) {
+	if err := AddConverter(fn); err != nil {
+		panic(err)
+	}
+}
+
+// AddConverter registers its converter like RegisterConverter, but returns an
+// error when fn isn't a func(string)(T,error), and T is derived from fn.
+func AddConverter(fn utils.Converter) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || !utils.IsConverter(t) {
+		return errors.New("converter is func(string)(type,error)")
+	}
+	converters[t.Out(0)] = fn
+	resetStructPlans()
+	return nil
+}
+
+// RegisterNamedConverter registers a converter and named it, a field can pick
+// it explicitly by the tag `converter:"name"`, for example, when time.Time
+// fields use different layouts.
+func RegisterNamedConverter(name string, fn utils.Converter) {
 	t := reflect.TypeOf(fn)
 	if !utils.IsConverter(t) {
 		panic(errors.New("converter is func(string)(type,error)"))
 	}
-	converters[t.Out(0)] = fn
+	namedConverters[name] = fn
+}
+
+// RegisterValueResolver registers a ValueResolver for the scheme, references
+// like ${scheme:key} are resolved by it instead of the Properties.
+func RegisterValueResolver(scheme string, fn ValueResolver) {
+	valueResolvers[scheme] = fn
+}
+
+// Registry is a snapshot of the global registries and settings, including the
+// readers, splitters, converters, value resolvers and validators.
+type Registry struct {
+	readers         map[string]Reader
+	splitters       map[string]Splitter
+	converters      map[reflect.Type]utils.Converter
+	namedConverters map[string]utils.Converter
+	valueResolvers  map[string]ValueResolver
+	validators      map[string]Validator
+	useJSONTagAsKey bool
+	strictStruct    bool
+	validateAll     bool
+}
+
+// Snapshot returns a snapshot of the global registries and settings, which can
+// be restored by Restore, e.g. t.Cleanup(func() { conf.Restore(conf.Snapshot()) })
+// in tests registering custom converters. They are not locked, so tests changing
+// them shouldn't run in parallel with those binding properties.
+func Snapshot() *Registry {
+	return &Registry{
+		readers:         maps.Clone(readers),
+		splitters:       maps.Clone(splitters),
+		converters:      maps.Clone(converters),
+		namedConverters: maps.Clone(namedConverters),
+		valueResolvers:  maps.Clone(valueResolvers),
+		validators:      maps.Clone(validators),
+		useJSONTagAsKey: useJSONTagAsKey,
+		strictStruct:    strictStruct,
+		validateAll:     validateAll,
+	}
+}
+
+// Restore restores the global registries and settings to the snapshot.
+func Restore(r *Registry) {
+	readers = maps.Clone(r.readers)
+	splitters = maps.Clone(r.splitters)
+	converters = maps.Clone(r.converters)
+	namedConverters = maps.Clone(r.namedConverters)
+	valueResolvers = maps.Clone(r.valueResolvers)
+	validators = maps.Clone(r.validators)
+	useJSONTagAsKey = r.useJSONTagAsKey
+	strictStruct = r.strictStruct
+	validateAll = r.validateAll
+	resetStructPlans()
 }
 
 // A Value represents a refreshable type.
@@ -119,7 +248,41 @@ type Value interface {
 // but it costs more CPU time when getting properties because it reads property node
 // by node. So `conf` uses a tree to strictly verify and a flat map to store.
 type Properties struct {
-	storage *internal.Storage
+	storage  Storage
+	watchers []*changeWatcher
+	splitter Splitter
+	origins  map[string]string
+}
+
+// changeWatcher is a callback watching the changes of a key or keys under a
+// prefix.
+type changeWatcher struct {
+	key    string
+	prefix bool
+	fn     func(key, oldVal, newVal string)
+}
+
+// Storage is the backend of Properties, the default one validates keys by a
+// tree and stores values in a flat map. Keys are like a.b[0].c.
+//
+// Keys returns the sorted keys that have values, a key of an empty map or an
+// empty list has an empty value. Has returns true for keys that have values
+// and for keys of maps or lists, e.g. a and a.b for a.b[0]. Get returns the
+// value of the key, or "" if it has no value. Set stores the value of the key
+// and returns an error if the key conflicts with existing ones on types, e.g.
+// a.b when a has a value. SubKeys returns the sorted names of a map or the
+// numerically sorted indexes of a list, nil if the key doesn't exist, and an
+// error if it has a value.
+//
+// Properties doesn't lock the storage, so a storage that is changed while
+// Properties reads it, e.g. synced from a remote config center, should be
+// safe for concurrent use by itself.
+type Storage interface {
+	Keys() []string
+	Has(key string) bool
+	Get(key string) string
+	Set(key, val string) error
+	SubKeys(key string) ([]string, error)
 }
 
 // New creates empty *Properties.
@@ -129,6 +292,13 @@ func New() *Properties {
 	}
 }
 
+// NewWithStorage creates *Properties backed by the storage.
+func NewWithStorage(s Storage) *Properties {
+	return &Properties{
+		storage: s,
+	}
+}
+
 // Map creates *Properties from map.
 func Map(m map[string]interface{}) *Properties {
 	p := New()
@@ -151,7 +321,7 @@ func (p *Properties) Load(file string) error {
 	if err != nil {
 		return err
 	}
-	return p.Bytes(b, filepath.Ext(file))
+	return p.bytes(b, filepath.Ext(file), file)
 }
 
 // Read creates *Properties from io.Reader, ext is the file name extension.
@@ -172,6 +342,42 @@ func (p *Properties) Read(r io.Reader, ext string) error {
 	return p.Bytes(b, ext)
 }
 
+// FromProperties creates *Properties from content in the properties format,
+// which has `key=value` lines, `#` comments and `\` line continuations, the
+// keys and values are trimmed, and references like ${a} are kept for Resolve.
+// Unlike the .properties files, trailing spaces of the values are trimmed too.
+func FromProperties(r io.Reader) (*Properties, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m, err := prop.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range m {
+		m[k] = strings.TrimSpace(v.(string))
+	}
+	p := New()
+	if err = p.Merge(m); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FromYAML creates *Properties from yaml content, nested maps are flattened
+// into keys like a.b.c and sequences into keys like a[0], null values become
+// empty values. The root of the content must be a map.
+func FromYAML(r io.Reader) (*Properties, error) {
+	return Read(r, ".yaml")
+}
+
+// FromTOML creates *Properties from toml content, tables are flattened into
+// keys like a.b.c and arrays into keys like a[0].
+func FromTOML(r io.Reader) (*Properties, error) {
+	return Read(r, ".toml")
+}
+
 // Bytes creates *Properties from []byte, ext is the file name extension.
 func Bytes(b []byte, ext string) (*Properties, error) {
 	p := New()
@@ -183,6 +389,10 @@ func Bytes(b []byte, ext string) (*Properties, error) {
 
 // Bytes loads properties from []byte, ext is the file name extension.
 func (p *Properties) Bytes(b []byte, ext string) error {
+	return p.bytes(b, ext, "")
+}
+
+func (p *Properties) bytes(b []byte, ext string, origin string) error {
 	r, ok := readers[ext]
 	if !ok {
 		return fmt.Errorf("unsupported file type %s", ext)
@@ -191,7 +401,7 @@ func (p *Properties) Bytes(b []byte, ext string) error {
 	if err != nil {
 		return err
 	}
-	return p.Merge(m)
+	return p.mergeFrom(Flatten(m), origin)
 }
 
 // Merge flattens the map and sets all keys and values.
@@ -201,22 +411,306 @@ func (p *Properties) Merge(m map[string]interface{}) error {
 }
 
 func (p *Properties) merge(m map[string]string) error {
+	return p.mergeFrom(m, "")
+}
+
+// mergeFrom sets all keys and values and records the origin of the keys, an
+// empty origin forgets the previous one, since it's overridden.
+func (p *Properties) mergeFrom(m map[string]string, origin string) error {
 	for key, val := range m {
 		if err := p.store(key, val); err != nil {
 			return err
 		}
+		p.setOrigin(key, origin)
 	}
 	return nil
 }
 
+func (p *Properties) setOrigin(key, origin string) {
+	if origin == "" {
+		delete(p.origins, key)
+		return
+	}
+	if p.origins == nil {
+		p.origins = make(map[string]string)
+	}
+	p.origins[key] = origin
+}
+
+// Origin returns the name of the file or resource that set the value of the
+// key last, or "" if the key was set in other ways, e.g. by Set, or doesn't
+// exist.
+func (p *Properties) Origin(key string) string {
+	return p.origins[key]
+}
+
 func (p *Properties) store(key, val string) error {
-	return p.storage.Set(key, val)
+	if len(p.watchers) == 0 {
+		return p.storage.Set(key, val)
+	}
+	existed, oldVal := p.storage.Has(key), p.storage.Get(key)
+	if err := p.storage.Set(key, val); err != nil {
+		return err
+	}
+	if !existed || oldVal != val {
+		p.notify(key, oldVal, val)
+	}
+	return nil
 }
 
+// notify calls the watchers of the changed key.
+func (p *Properties) notify(key, oldVal, newVal string) {
+	for _, w := range p.watchers {
+		if key == w.key || (w.prefix && isSubKey(key, w.key)) {
+			w.fn(key, oldVal, newVal)
+		}
+	}
+}
+
+// isSubKey returns whether the key is under the prefix, like a.b or a[0]
+// for the prefix a, an empty prefix contains all keys.
+func isSubKey(key, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	if !strings.HasPrefix(key, prefix) {
+		return false
+	}
+	s := key[len(prefix):]
+	return s == "" || s[0] == '.' || s[0] == '['
+}
+
+// OnChange registers a callback which is called when the value of a key is
+// changed to a different one by Set, Merge, MergeWith or loading, writing a
+// same value doesn't call it. A new key is changed from an empty value, and a
+// key removed by MergeWith is changed to an empty value.
+func (p *Properties) OnChange(key string, fn func(oldVal, newVal string)) {
+	p.watch(&changeWatcher{
+		key: key,
+		fn: func(_, oldVal, newVal string) {
+			fn(oldVal, newVal)
+		},
+	})
+}
+
+// OnPrefixChange registers a callback like OnChange, but it watches the key
+// and all keys under the key, such as a.b and a[0] for the prefix a.
+func (p *Properties) OnPrefixChange(prefix string, fn func(key, oldVal, newVal string)) {
+	p.watch(&changeWatcher{
+		key:    prefix,
+		prefix: true,
+		fn:     fn,
+	})
+}
+
+// watch registers the watcher, and returns a function to unregister it.
+func (p *Properties) watch(w *changeWatcher) (cancel func()) {
+	p.watchers = append(p.watchers, w)
+	return func() {
+		for i, x := range p.watchers {
+			if x == w {
+				p.watchers = append(p.watchers[:i:i], p.watchers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// MergePolicy decides which value wins when merging two Properties that both
+// define a same key.
+type MergePolicy int
+
+const (
+	Override        = MergePolicy(iota) // the other's value wins.
+	KeepExisting                        // the existing value wins.
+	ErrorOnConflict                     // returns an error listing the conflict keys.
+)
+
+// MergeWith merges the other Properties into p according to the policy. Lists
+// are treated as a whole, that means a list is replaced wholesale by the winner
+// instead of being merged element by element or concatenated. When the policy
+// is ErrorOnConflict, keys that both define with different values (or lists
+// that differ in any element) are reported and p remains unchanged.
+//
+// The keys are merged one by one into the storage of p. Replacing a longer
+// list or a value of another type needs to remove keys, which is an error for
+// a custom storage without a `Remove(key string)` method.
+func (p *Properties) MergeWith(other *Properties, policy MergePolicy) error {
+	var r *Properties
+	switch policy {
+	case Override:
+		r = overlay(other, p)
+	case KeepExisting:
+		r = overlay(p, other)
+	case ErrorOnConflict:
+		if keys := conflictKeys(p, other); len(keys) > 0 {
+			return fmt.Errorf("merge conflict on keys [%s]", strings.Join(keys, ", "))
+		}
+		r = overlay(p, other)
+	default:
+		return fmt.Errorf("unknown merge policy %d", policy)
+	}
+	return p.replace(r)
+}
+
+// keyRemover is implemented by storages that can remove keys, like the
+// default one.
+type keyRemover interface {
+	Remove(key string)
+}
+
+// replace changes the keys of p to the ones of r, the keys that r doesn't
+// have are removed first, and then the new or different values are stored
+// with the origins of r. The watchers of the removed keys are notified with empty new values at
+// last, so that they see the merged properties.
+func (p *Properties) replace(r *Properties) error {
+	keys := make(map[string]struct{})
+	for _, key := range r.Keys() {
+		keys[key] = struct{}{}
+	}
+	var removed []string
+	for _, key := range p.Keys() {
+		if _, ok := keys[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	if len(removed) > 0 {
+		s, ok := p.storage.(keyRemover)
+		if !ok {
+			return fmt.Errorf("storage can't remove keys [%s]", strings.Join(removed, ", "))
+		}
+		oldVals := make([]string, len(removed))
+		for i, key := range removed {
+			oldVals[i] = p.storage.Get(key)
+			s.Remove(key)
+		}
+		defer func() {
+			for i, key := range removed {
+				if !p.storage.Has(key) {
+					p.notify(key, oldVals[i], "")
+				}
+			}
+		}()
+	}
+	for _, key := range r.Keys() {
+		val := r.Get(key)
+		if p.storage.Has(key) && p.storage.Get(key) == val {
+			continue
+		}
+		if err := p.store(key, val); err != nil {
+			return err
+		}
+	}
+	p.origins = r.origins
+	return nil
+}
+
+// overlay returns a new *Properties that contains all keys of base, and keys
+// of other which don't conflict with base.
+func overlay(base, other *Properties) *Properties {
+	r := base.Copy()
+	lists := listRoots(base)
+	for _, key := range other.Keys() {
+		if _, ok := lists[listRoot(key)]; ok {
+			continue
+		}
+		if r.Has(key) {
+			continue
+		}
+		// a key that conflicts with the tree structure of base is dropped.
+		if r.store(key, other.Get(key)) == nil {
+			r.setOrigin(key, other.origins[key])
+		}
+	}
+	return r
+}
+
+// conflictKeys returns the sorted keys (or list keys) both defined by p and
+// other but with different values.
+func conflictKeys(p, other *Properties) []string {
+	conflicts := make(map[string]struct{})
+	pLists, otherLists := listRoots(p), listRoots(other)
+	for root := range otherLists {
+		if _, ok := pLists[root]; !ok {
+			continue
+		}
+		if !reflect.DeepEqual(subMap(p, root), subMap(other, root)) {
+			conflicts[root] = struct{}{}
+		}
+	}
+	leaves := make(map[string]struct{})
+	for _, key := range p.Keys() {
+		leaves[key] = struct{}{}
+	}
+	r := p.Copy()
+	for _, key := range other.Keys() {
+		if _, ok := pLists[listRoot(key)]; ok {
+			continue
+		}
+		if _, ok := leaves[key]; ok {
+			if p.Get(key) != other.Get(key) {
+				conflicts[key] = struct{}{}
+			}
+			continue
+		}
+		// p defines a map or list at the key, or a value at its parent.
+		if r.Has(key) || r.store(key, other.Get(key)) != nil {
+			conflicts[key] = struct{}{}
+		}
+	}
+	return utils.SortedKeys(conflicts)
+}
+
+// listRoot returns the outermost list key of key, for example a.b for the
+// key a.b[0].c, or empty string when the key isn't in a list.
+func listRoot(key string) string {
+	path, err := internal.SplitPath(key)
+	if err != nil {
+		return ""
+	}
+	for i, node := range path {
+		if node.Type == internal.PathTypeIndex {
+			return internal.JoinPath(path[:i])
+		}
+	}
+	return ""
+}
+
+// listRoots returns all outermost list keys of p.
+func listRoots(p *Properties) map[string]struct{} {
+	roots := make(map[string]struct{})
+	for _, key := range p.Keys() {
+		if root := listRoot(key); root != "" {
+			roots[root] = struct{}{}
+		}
+	}
+	return roots
+}
+
+// subMap returns key-value pairs whose keys start with the list key root.
+func subMap(p *Properties, root string) map[string]string {
+	m := make(map[string]string)
+	for _, key := range p.Keys() {
+		if strings.HasPrefix(key, root+"[") {
+			m[key] = p.Get(key)
+		}
+	}
+	return m
+}
+
+// Copy returns a copy of the properties, a custom storage is copied into the
+// default one.
 func (p *Properties) Copy() *Properties {
-	return &Properties{
-		storage: p.storage.Copy(),
+	if s, ok := p.storage.(*internal.Storage); ok {
+		return &Properties{storage: s.Copy(), splitter: p.splitter, origins: maps.Clone(p.origins)}
+	}
+	r := New()
+	r.splitter = p.splitter
+	r.origins = maps.Clone(p.origins)
+	for _, key := range p.storage.Keys() {
+		_ = r.storage.Set(key, p.storage.Get(key))
 	}
+	return r
 }
 
 // Keys returns all sorted keys.
@@ -224,6 +718,54 @@ func (p *Properties) Keys() []string {
 	return p.storage.Keys()
 }
 
+// KeysWithPrefix returns the sorted keys which are the prefix itself or under
+// the prefix, e.g. a.b, a.c[0] and a[0] for the prefix a. Like Keys, items of
+// lists are represented as a[0], and the keys are sorted by string order.
+func (p *Properties) KeysWithPrefix(prefix string) []string {
+	if prefix == "" {
+		return p.Keys()
+	}
+	var keys []string
+	for _, key := range p.storage.Keys() {
+		if isSubKey(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Sub returns a copy of the properties under the prefix, whose keys have the
+// prefix stripped, e.g. a.b.c becomes b.c for the prefix a, so it can be bound
+// without knowing the prefix. Items of the list named by the prefix itself,
+// like a[0], have no key after stripping and are left out.
+func (p *Properties) Sub(prefix string) *Properties {
+	if prefix == "" {
+		return p.Copy()
+	}
+	r := New()
+	r.splitter = p.splitter
+	for _, key := range p.KeysWithPrefix(prefix) {
+		if s := key[len(prefix):]; strings.HasPrefix(s, ".") {
+			_ = r.storage.Set(s[1:], p.storage.Get(key))
+			r.setOrigin(s[1:], p.origins[key])
+		}
+	}
+	return r
+}
+
+// SubKeys returns the sorted names of a map or indexes of a list, nil if the
+// key doesn't exist, and an error if it has a value. The order is stable, so
+// that the maps bound from them are reproducible.
+func (p *Properties) SubKeys(key string) ([]string, error) {
+	return p.storage.SubKeys(key)
+}
+
+// SetDefaultSplitter sets the Splitter that splits a string value into a slice
+// when the tag has no splitter, nil restores the default one splitting by comma.
+func (p *Properties) SetDefaultSplitter(fn Splitter) {
+	p.splitter = fn
+}
+
 // Has returns whether key exists.
 func (p *Properties) Has(key string) bool {
 	return p.storage.Has(key)
@@ -262,20 +804,54 @@ func (p *Properties) Get(key string, opts ...GetOption) string {
 // when it doesn't exist in the slice or map even they share a same
 // prefix path.
 func (p *Properties) Set(key string, val interface{}) error {
+	return p.SetFrom(key, val, "")
+}
+
+// SetFrom is like Set, and records the origin of the keys, e.g. the name of
+// the resource that the value is read from, see Origin.
+func (p *Properties) SetFrom(key string, val interface{}, origin string) error {
 	if key == "" {
 		return nil
 	}
 	m := make(map[string]string)
 	flatten(key, val, m)
-	return p.merge(m)
+	return p.mergeFrom(m, origin)
 }
 
 // Resolve resolves string value that contains references to other
-// properties, the references are defined by ${key:=def}.
+// properties, the references are defined by ${key:=def}. The references are
+// resolved recursively, and it returns an error when they form a cycle. Use
+// $${ for a literal ${ which isn't a reference.
 func (p *Properties) Resolve(s string) (string, error) {
 	return resolveString(p, s)
 }
 
+var probeTypes = map[string]reflect.Type{
+	"int":      reflect.TypeOf(int64(0)),
+	"uint":     reflect.TypeOf(uint64(0)),
+	"float":    reflect.TypeOf(float64(0)),
+	"bool":     reflect.TypeOf(false),
+	"string":   reflect.TypeOf(""),
+	"duration": reflect.TypeOf(time.Duration(0)),
+	"time":     reflect.TypeOf(time.Time{}),
+}
+
+// Probe resolves key's value and parses it as the type named typeName, which
+// can be int, uint, float, bool, string, duration or time. It returns the parsed
+// value, or an error describing why the value can't be parsed as that type.
+func (p *Properties) Probe(key, typeName string) (interface{}, error) {
+	t, ok := probeTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("probe %s error: unsupported type %q", key, typeName)
+	}
+	v := reflect.New(t).Elem()
+	param := BindParam{Key: key, Path: key}
+	if err := BindValue(p, v, t, param, nil); err != nil {
+		return nil, fmt.Errorf("probe %s as %s error: %w", key, typeName, err)
+	}
+	return v.Interface(), nil
+}
+
 type BindArg interface {
 	getParam() (BindParam, error)
 }
@@ -316,12 +892,80 @@ func Param(param BindParam) BindArg {
 	return paramArg{param: param}
 }
 
+type optionArg struct {
+	apply func(param *BindParam)
+}
+
+func (arg optionArg) getParam() (BindParam, error) {
+	return tagArg{tag: "${ROOT}"}.getParam()
+}
+
+// ZeroOnMissing leaves values at their zero values when their keys are absent
+// and have no default, instead of reporting an error. It can be used together
+// with Key, Tag or Param, and doesn't hide errors of present values.
+func ZeroOnMissing() BindArg {
+	return optionArg{apply: func(param *BindParam) {
+		param.ZeroOnMissing = true
+	}}
+}
+
+// Lenient parses bools and integers leniently, bools also accept yes/no and
+// on/off in any case, and integers may have thousands separators like 1,000.
+// Parsing is strict by default.
+func Lenient() BindArg {
+	return optionArg{apply: func(param *BindParam) {
+		param.Lenient = true
+	}}
+}
+
+// bindParamOf returns the BindParam of the first non option arg, or of the
+// ${ROOT} tag, with the options applied.
+func bindParamOf(args []BindArg) (BindParam, error) {
+	var (
+		arg     BindArg = tagArg{tag: "${ROOT}"}
+		options []optionArg
+	)
+	found := false
+	for _, a := range args {
+		if o, ok := a.(optionArg); ok {
+			options = append(options, o)
+		} else if !found {
+			arg, found = a, true
+		}
+	}
+	param, err := arg.getParam()
+	if err != nil {
+		return BindParam{}, err
+	}
+	for _, o := range options {
+		o.apply(&param)
+	}
+	return param, nil
+}
+
 // Bind binds properties to a value, the bind value can be primitive type,
 // map, slice, struct. When binding to struct, the tag 'value' indicates
 // which properties should be bind. The 'value' tags are defined by
 // value:"${a:=b|splitter}", 'a' is the key, 'b' is the default value,
 // 'splitter' is the Splitter's name when you want split string value
-// into []string value.
+// into []string value. The tag `required:"true"` reports an error when neither
+// the key nor its candidates or aliases has a value, defaults don't count, or
+// the resolved value is empty, also for maps, slices and structs, and the
+// tag like `required:"fields.Enabled"` requires it only when the expr on the
+// sibling fields declared before it returns true. The tag
+// `alias:"old.key"` keeps accepting deprecated names when the key is absent.
+// The tag like `transform:"trim,lower"` transforms string values in order
+// before they're validated, the transforms are trim, lower and upper.
+//
+// When the key is absent, ${a} reports an error except for maps, which bind
+// an empty map. ${a:=} is an explicit empty default, it binds "" to strings,
+// zero to numbers, false to bools, and empty slices or maps. ${a:=b} binds b,
+// which is split for slices, and is an error for maps and structs. With the
+// ZeroOnMissing arg, absent keys leave their values zero instead of errors.
+// Lists must be contiguous, a[0] and a[2] without a[1] is an error.
+//
+// interface{} binds []interface{} for lists, map[string]interface{} for maps,
+// and bool, int64, float64 or string inferred from the value.
 func (p *Properties) Bind(i interface{}, args ...BindArg) error {
 
 	var v reflect.Value
@@ -338,17 +982,13 @@ func (p *Properties) Bind(i interface{}, args ...BindArg) error {
 		}
 	}
 
-	if len(args) == 0 {
-		args = []BindArg{tagArg{tag: "${ROOT}"}}
-	}
-
 	t := v.Type()
 	typeName := t.Name()
 	if typeName == "" { // primitive type has no name
 		typeName = t.String()
 	}
 
-	param, err := args[0].getParam()
+	param, err := bindParamOf(args)
 	if err != nil {
 		return err
 	}
@@ -17,7 +17,11 @@
 package conf
 
 import (
+	"fmt"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/limpo1989/go-spring/internal/utils/assert"
 )
@@ -28,6 +32,85 @@ func TestRegisterConverter(t *testing.T) {
 	}, "converter is func\\(string\\)\\(type,error\\)")
 }
 
+type color int
+
+const (
+	red color = iota + 1
+	green
+)
+
+func TestAddConverter(t *testing.T) {
+
+	r := Snapshot()
+	t.Cleanup(func() { Restore(r) })
+
+	err := AddConverter(func(s string) color { return red })
+	assert.Error(t, err, "converter is func\\(string\\)\\(type,error\\)")
+
+	err = AddConverter(func(a, b string) (color, error) { return red, nil })
+	assert.Error(t, err, "converter is func\\(string\\)\\(type,error\\)")
+
+	err = AddConverter(nil)
+	assert.Error(t, err, "converter is func\\(string\\)\\(type,error\\)")
+
+	err = AddConverter(func(s string) (color, error) {
+		switch s {
+		case "red":
+			return red, nil
+		case "green":
+			return green, nil
+		}
+		return 0, fmt.Errorf("unknown color %q", s)
+	})
+	assert.Nil(t, err)
+
+	var v struct {
+		Color  color   `value:"${color}"`
+		Colors []color `value:"${colors:=red,green}"`
+	}
+	err = Map(map[string]interface{}{"color": "green"}).Bind(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, v.Color, green)
+	assert.Equal(t, v.Colors, []color{red, green})
+
+	err = Map(map[string]interface{}{"color": "blue"}).Bind(&v)
+	assert.Error(t, err, "bind .*Color error: unknown color \"blue\"")
+}
+
+func TestRestore(t *testing.T) {
+
+	type weekday int
+
+	var v struct {
+		Day weekday `value:"${day}"`
+	}
+	p := Map(map[string]interface{}{"day": "mon"})
+
+	r := Snapshot()
+	RegisterConverter(func(s string) (weekday, error) {
+		if s == "mon" {
+			return 1, nil
+		}
+		return 0, fmt.Errorf("unknown weekday %q", s)
+	})
+	RegisterSplitter("snapshot", func(s string) ([]string, error) {
+		return strings.Fields(s), nil
+	})
+	SetStrictStruct(true)
+
+	err := p.Bind(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, v.Day, weekday(1))
+	assert.NotNil(t, splitters["snapshot"])
+
+	Restore(r)
+	assert.False(t, strictStruct)
+	assert.Nil(t, splitters["snapshot"])
+
+	err = p.Bind(&v)
+	assert.Error(t, err, "strconv.ParseInt: parsing \"mon\": invalid syntax")
+}
+
 func TestLoad(t *testing.T) {
 
 	_, err := Load("nonexisting.yaml")
@@ -55,6 +138,106 @@ func TestBytes(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestFromProperties(t *testing.T) {
+
+	p, err := FromProperties(strings.NewReader(`
+		# comment
+		! comment too
+		host = localhost
+		  port=8080  
+		hosts = a,\
+		        b,\
+		        c
+		url = ${host}:${port}
+		quoted = "hello"
+	`))
+	assert.Nil(t, err)
+	assert.Equal(t, p.Keys(), []string{"host", "hosts", "port", "quoted", "url"})
+	assert.Equal(t, p.Get("host"), "localhost")
+	assert.Equal(t, p.Get("port"), "8080")
+	assert.Equal(t, p.Get("hosts"), "a,b,c")
+	assert.Equal(t, p.Get("url"), "${host}:${port}")
+	assert.Equal(t, p.Get("quoted"), `"hello"`)
+
+	var url string
+	err = p.Bind(&url, Key("url"))
+	assert.Nil(t, err)
+	assert.Equal(t, url, "localhost:8080")
+
+	p, err = Read(strings.NewReader(`export NAME="hello world"`), ".env")
+	assert.Nil(t, err)
+	assert.Equal(t, p.Get("NAME"), "hello world")
+}
+
+func TestFromYAML(t *testing.T) {
+
+	str := `
+		server:
+			host: localhost
+			ports: [80, 443]
+			backup: ~
+			routes:
+				- path: /a
+				  weight: 1
+				- path: /b
+				  weight: 2
+	`
+	p, err := FromYAML(strings.NewReader(strings.ReplaceAll(str, "\t", "  ")))
+	assert.Nil(t, err)
+	assert.Equal(t, p.Keys(), []string{
+		"server.backup",
+		"server.host",
+		"server.ports[0]",
+		"server.ports[1]",
+		"server.routes[0].path",
+		"server.routes[0].weight",
+		"server.routes[1].path",
+		"server.routes[1].weight",
+	})
+	assert.Equal(t, p.Get("server.backup"), "")
+
+	type Route struct {
+		Path   string `value:"${path}"`
+		Weight int    `value:"${weight}"`
+	}
+	var s struct {
+		Host   string  `value:"${host}"`
+		Ports  []int   `value:"${ports}"`
+		Backup string  `value:"${backup}"`
+		Routes []Route `value:"${routes}"`
+	}
+	err = p.Bind(&s, Key("server"))
+	assert.Nil(t, err)
+	assert.Equal(t, s.Host, "localhost")
+	assert.Equal(t, s.Ports, []int{80, 443})
+	assert.Equal(t, s.Routes, []Route{{"/a", 1}, {"/b", 2}})
+
+	_, err = FromYAML(strings.NewReader("hello"))
+	assert.Error(t, err, "yaml root should be a map but got string")
+}
+
+func TestFromTOML(t *testing.T) {
+
+	p, err := FromTOML(strings.NewReader(`
+		name = "app"
+		[server]
+		host = "localhost"
+		ports = [80, 443]
+	`))
+	assert.Nil(t, err)
+	assert.Equal(t, p.Keys(), []string{
+		"name",
+		"server.host",
+		"server.ports[0]",
+		"server.ports[1]",
+	})
+
+	var ports []int
+	err = p.Bind(&ports, Key("server.ports"))
+	assert.Nil(t, err)
+	assert.Equal(t, ports, []int{80, 443})
+}
+
 func TestProperties(t *testing.T) {
 	p := Map(map[string]interface{}{
 		"int":   1,
@@ -103,6 +286,84 @@ func TestProperties_Merge(t *testing.T) {
 	assert.Error(t, err, "property 'a' is an array but 'a\\.c' wants other type")
 }
 
+func TestProperties_MergeWith(t *testing.T) {
+
+	base := func() *Properties {
+		return Map(map[string]interface{}{
+			"a":    "1",
+			"b":    map[string]string{"c": "2"},
+			"list": []int{1, 2, 3},
+		})
+	}
+
+	overlay := Map(map[string]interface{}{
+		"a":    "10",
+		"d":    "4",
+		"list": []int{9},
+	})
+
+	t.Run("override", func(t *testing.T) {
+		p := base()
+		err := p.MergeWith(overlay, Override)
+		assert.Nil(t, err)
+		assert.Equal(t, p.Keys(), []string{"a", "b.c", "d", "list[0]"})
+		assert.Equal(t, p.Get("a"), "10")
+		assert.Equal(t, p.Get("list[0]"), "9")
+	})
+
+	t.Run("keep existing", func(t *testing.T) {
+		p := base()
+		err := p.MergeWith(overlay, KeepExisting)
+		assert.Nil(t, err)
+		assert.Equal(t, p.Keys(), []string{"a", "b.c", "d", "list[0]", "list[1]", "list[2]"})
+		assert.Equal(t, p.Get("a"), "1")
+		assert.Equal(t, p.Get("list[0]"), "1")
+	})
+
+	t.Run("error on conflict", func(t *testing.T) {
+		p := base()
+		err := p.MergeWith(overlay, ErrorOnConflict)
+		assert.Error(t, err, "merge conflict on keys \\[a, list]")
+		assert.Equal(t, p.Get("a"), "1")
+		assert.False(t, p.Has("d"))
+
+		err = p.MergeWith(Map(map[string]interface{}{
+			"a":    "1",
+			"d":    "4",
+			"list": []int{1, 2, 3},
+		}), ErrorOnConflict)
+		assert.Nil(t, err)
+		assert.Equal(t, p.Get("d"), "4")
+	})
+
+	t.Run("type conflict", func(t *testing.T) {
+		p := base()
+		other := Map(map[string]interface{}{
+			"a": map[string]string{"x": "1"},
+			"b": "3",
+		})
+		err := p.MergeWith(other, ErrorOnConflict)
+		assert.Error(t, err, "merge conflict on keys \\[a.x, b]")
+
+		err = p.MergeWith(other, Override)
+		assert.Nil(t, err)
+		assert.Equal(t, p.Get("a.x"), "1")
+		assert.Equal(t, p.Get("b"), "3")
+	})
+
+	t.Run("custom storage", func(t *testing.T) {
+		s := mapStorage{"a": "1", "list[0]": "1", "list[1]": "2"}
+		p := NewWithStorage(s)
+		err := p.MergeWith(Map(map[string]interface{}{"a": "10", "d": "4"}), Override)
+		assert.Nil(t, err)
+		assert.Equal(t, s, mapStorage{"a": "10", "d": "4", "list[0]": "1", "list[1]": "2"})
+
+		// the shorter list needs to remove list[1].
+		err = p.MergeWith(overlay, Override)
+		assert.Error(t, err, "storage can't remove keys \\[list\\[1]]")
+	})
+}
+
 ////func TestProperties_Load(t *testing.T) {
 ////
 ////	p := conf.New()
@@ -313,6 +574,293 @@ func TestProperties_Merge(t *testing.T) {
 //	})
 //}
 
+func TestProperties_KeysWithPrefix(t *testing.T) {
+	p := Map(map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": "1",
+			"c": []interface{}{"x", map[string]interface{}{"d": "y"}},
+		},
+		"ab":  "2",
+		"arr": []string{"m", "n"},
+		"x":   "3",
+	})
+	assert.Equal(t, p.KeysWithPrefix("a"), []string{"a.b", "a.c[0]", "a.c[1].d"})
+	assert.Equal(t, p.KeysWithPrefix("a.c"), []string{"a.c[0]", "a.c[1].d"})
+	assert.Equal(t, p.KeysWithPrefix("a.c[1]"), []string{"a.c[1].d"})
+	assert.Equal(t, p.KeysWithPrefix("arr"), []string{"arr[0]", "arr[1]"})
+	assert.Equal(t, p.KeysWithPrefix("x"), []string{"x"})
+	assert.Equal(t, len(p.KeysWithPrefix("y")), 0)
+	assert.Equal(t, p.KeysWithPrefix(""), p.Keys())
+}
+
+func TestProperties_SubKeys(t *testing.T) {
+	p := Map(map[string]interface{}{
+		"m": map[string]interface{}{"b": 1, "a": 2, "c": 3},
+		"s": []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		"v": "x",
+	})
+
+	keys, err := p.SubKeys("m")
+	assert.Nil(t, err)
+	assert.Equal(t, keys, []string{"a", "b", "c"})
+
+	keys, err = p.SubKeys("s")
+	assert.Nil(t, err)
+	assert.Equal(t, keys, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10"})
+
+	keys, err = p.SubKeys("none")
+	assert.Nil(t, err)
+	assert.Nil(t, keys)
+
+	_, err = p.SubKeys("v")
+	assert.Error(t, err, "property 'v' is value")
+}
+
+func TestProperties_Origin(t *testing.T) {
+	p := New()
+	err := p.Load("testdata/application.properties")
+	assert.Nil(t, err)
+	assert.Equal(t, p.Origin("properties.list[0]"), "testdata/application.properties")
+
+	err = p.SetFrom("properties.list[0]", "3", "override.properties")
+	assert.Nil(t, err)
+	assert.Equal(t, p.Origin("properties.list[0]"), "override.properties")
+	assert.Equal(t, p.Origin("properties.list[1]"), "testdata/application.properties")
+
+	c := p.Copy()
+	assert.Equal(t, c.Origin("properties.list[0]"), "override.properties")
+	s := p.Sub("properties")
+	assert.Equal(t, s.Origin("list[1]"), "testdata/application.properties")
+
+	_ = p.Set("properties.list[0]", "4")
+	assert.Equal(t, p.Origin("properties.list[0]"), "")
+	assert.Equal(t, c.Origin("properties.list[0]"), "override.properties")
+	assert.Equal(t, p.Origin("none"), "")
+
+	a, b := New(), New()
+	_ = a.SetFrom("x", "1", "a.yaml")
+	_ = a.SetFrom("y", "1", "a.yaml")
+	_ = b.SetFrom("x", "2", "b.yaml")
+	_ = b.SetFrom("z", "2", "b.yaml")
+
+	m := a.Copy()
+	err = m.MergeWith(b, Override)
+	assert.Nil(t, err)
+	assert.Equal(t, m.Origin("x"), "b.yaml")
+	assert.Equal(t, m.Origin("y"), "a.yaml")
+	assert.Equal(t, m.Origin("z"), "b.yaml")
+
+	m = a.Copy()
+	err = m.MergeWith(b, KeepExisting)
+	assert.Nil(t, err)
+	assert.Equal(t, m.Origin("x"), "a.yaml")
+	assert.Equal(t, m.Origin("z"), "b.yaml")
+}
+
+func TestProperties_Sub(t *testing.T) {
+	p := Map(map[string]interface{}{
+		"database": map[string]interface{}{
+			"url":   "localhost:3306",
+			"pool":  map[string]interface{}{"max": 10},
+			"hosts": []string{"a", "b"},
+		},
+		"server": map[string]interface{}{"port": 8080},
+	})
+
+	sub := p.Sub("database")
+	assert.Equal(t, sub.Keys(), []string{"hosts[0]", "hosts[1]", "pool.max", "url"})
+
+	var db struct {
+		URL   string   `value:"${url}"`
+		Max   int      `value:"${pool.max}"`
+		Hosts []string `value:"${hosts}"`
+	}
+	err := sub.Bind(&db)
+	assert.Nil(t, err)
+	assert.Equal(t, db.URL, "localhost:3306")
+	assert.Equal(t, db.Max, 10)
+	assert.Equal(t, db.Hosts, []string{"a", "b"})
+
+	// the sub-view is a copy.
+	_ = sub.Set("url", "remote:3306")
+	assert.Equal(t, p.Get("database.url"), "localhost:3306")
+
+	assert.Equal(t, p.Sub("database.pool").Keys(), []string{"max"})
+	assert.Equal(t, len(p.Sub("database.hosts").Keys()), 0)
+	assert.Equal(t, len(p.Sub("none").Keys()), 0)
+}
+
+// mapStorage is a trivial Storage backed by a flat map.
+type mapStorage map[string]string
+
+func (s mapStorage) Keys() []string {
+	var keys []string
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s mapStorage) Has(key string) bool {
+	for k := range s {
+		if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(k, key+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s mapStorage) Get(key string) string {
+	return s[key]
+}
+
+func (s mapStorage) Set(key, val string) error {
+	s[key] = val
+	return nil
+}
+
+func (s mapStorage) SubKeys(key string) ([]string, error) {
+	if _, ok := s[key]; ok {
+		return nil, fmt.Errorf("property '%s' is value", key)
+	}
+	set := make(map[string]struct{})
+	for k := range s {
+		var rest string
+		switch {
+		case strings.HasPrefix(k, key+"."):
+			rest = k[len(key)+1:]
+		case strings.HasPrefix(k, key+"["):
+			rest = k[len(key)+1:]
+			rest = rest[:strings.IndexByte(rest, ']')]
+		default:
+			continue
+		}
+		if i := strings.IndexAny(rest, ".["); i > 0 {
+			rest = rest[:i]
+		}
+		set[rest] = struct{}{}
+	}
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func TestNewWithStorage(t *testing.T) {
+	s := mapStorage{
+		"server.host":     "localhost",
+		"server.ports[0]": "80",
+		"server.ports[1]": "443",
+		"server.tags.env": "dev",
+		"name":            "${server.host}:${server.ports[0]}",
+	}
+	p := NewWithStorage(s)
+
+	var v struct {
+		Name   string `value:"${name}"`
+		Server struct {
+			Host  string            `value:"${host}"`
+			Ports []int             `value:"${ports}"`
+			Tags  map[string]string `value:"${tags}"`
+		} `value:"${server}"`
+	}
+	err := p.Bind(&v)
+	assert.Nil(t, err)
+	assert.Equal(t, v.Name, "localhost:80")
+	assert.Equal(t, v.Server.Host, "localhost")
+	assert.Equal(t, v.Server.Ports, []int{80, 443})
+	assert.Equal(t, v.Server.Tags, map[string]string{"env": "dev"})
+
+	// changes of the storage are visible.
+	s["server.host"] = "remote"
+	assert.Equal(t, p.Get("server.host"), "remote")
+
+	c := p.Copy()
+	assert.Equal(t, c.Keys(), p.Keys())
+	_ = c.Set("name", "copy")
+	assert.Equal(t, s["name"], "${server.host}:${server.ports[0]}")
+}
+
+func TestProperties_OnChange(t *testing.T) {
+	p := Map(map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "localhost",
+			"ports": []int{80, 443},
+		},
+	})
+
+	var changes, prefixChanges []string
+	p.OnChange("server.host", func(oldVal, newVal string) {
+		changes = append(changes, oldVal+"->"+newVal)
+	})
+	p.OnPrefixChange("server", func(key, oldVal, newVal string) {
+		prefixChanges = append(prefixChanges, key+":"+oldVal+"->"+newVal)
+	})
+
+	_ = p.Set("server.host", "localhost")
+	_ = p.Set("server.ports[0]", 80)
+	assert.Equal(t, len(changes), 0)
+	assert.Equal(t, len(prefixChanges), 0)
+
+	_ = p.Set("server.host", "remote")
+	_ = p.Set("server.ports[1]", 8443)
+	_ = p.Set("server.name", "web")
+	_ = p.Set("serverless", "true")
+	assert.Equal(t, changes, []string{"localhost->remote"})
+	assert.Equal(t, prefixChanges, []string{
+		"server.host:localhost->remote",
+		"server.ports[1]:443->8443",
+		"server.name:->web",
+	})
+
+	err := p.Merge(map[string]interface{}{
+		"server": map[string]interface{}{"host": "local"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, changes, []string{"localhost->remote", "remote->local"})
+}
+
+func TestProperties_OnChange_MergeWith(t *testing.T) {
+	p := Map(map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "localhost",
+			"ports": []int{80, 443},
+		},
+	})
+
+	var changes, prefixChanges []string
+	p.OnChange("server.host", func(oldVal, newVal string) {
+		changes = append(changes, oldVal+"->"+newVal)
+	})
+	p.OnPrefixChange("server.ports", func(key, oldVal, newVal string) {
+		// the watchers see the merged properties.
+		assert.Equal(t, p.Keys(), []string{"server.host", "server.ports[0]"})
+		prefixChanges = append(prefixChanges, key+":"+oldVal+"->"+newVal)
+	})
+
+	err := p.MergeWith(Map(map[string]interface{}{
+		"server": map[string]interface{}{"host": "remote"},
+	}), KeepExisting)
+	assert.Nil(t, err)
+	assert.Equal(t, len(changes), 0)
+
+	err = p.MergeWith(Map(map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "remote",
+			"ports": []int{8080},
+		},
+	}), Override)
+	assert.Nil(t, err)
+	assert.Equal(t, changes, []string{"localhost->remote"})
+	assert.Equal(t, prefixChanges, []string{
+		"server.ports[0]:80->8080",
+		"server.ports[1]:443->",
+	})
+}
+
 func TestResolve(t *testing.T) {
 	p := New()
 
@@ -337,6 +885,73 @@ func TestResolve(t *testing.T) {
 	str, err = p.Resolve("my name is ${name} my name is ${name}")
 	assert.Nil(t, err)
 	assert.Equal(t, str, "my name is Jim my name is Jim")
+
+	str, err = p.Resolve("hello ${nick:=${name}}")
+	assert.Nil(t, err)
+	assert.Equal(t, str, "hello Jim")
+
+	_ = p.Set("full", "${name} Green")
+	_ = p.Set("greeting", "hello ${full}")
+	str, err = p.Resolve("${greeting}!")
+	assert.Nil(t, err)
+	assert.Equal(t, str, "hello Jim Green!")
+
+	_ = p.Set("a", "${b}")
+	_ = p.Set("b", "x${c}")
+	_ = p.Set("c", "${a}")
+	_, err = p.Resolve("${a}")
+	assert.Error(t, err, "found cycle reference a -> b -> c -> a")
+
+	str, err = p.Resolve("echo $${HOME} for ${name}, $${USER}")
+	assert.Nil(t, err)
+	assert.Equal(t, str, "echo ${HOME} for Jim, ${USER}")
+
+	_ = p.Set("script", "echo $${PATH} by ${name}")
+	var script string
+	err = p.Bind(&script, Key("script"))
+	assert.Nil(t, err)
+	assert.Equal(t, script, "echo ${PATH} by Jim")
+
+	_ = p.Set("self", "${self}")
+	_, err = p.Resolve("${self}")
+	assert.Error(t, err, "found cycle reference self -> self")
+}
+
+func TestProperties_Probe(t *testing.T) {
+	p := Map(map[string]interface{}{
+		"port":    "8080",
+		"timeout": "5s",
+		"debug":   "true",
+		"ref":     "${port}",
+	})
+
+	v, err := p.Probe("port", "int")
+	assert.Nil(t, err)
+	assert.Equal(t, v, int64(8080))
+
+	v, err = p.Probe("ref", "uint")
+	assert.Nil(t, err)
+	assert.Equal(t, v, uint64(8080))
+
+	v, err = p.Probe("timeout", "duration")
+	assert.Nil(t, err)
+	assert.Equal(t, v, 5*time.Second)
+
+	v, err = p.Probe("debug", "bool")
+	assert.Nil(t, err)
+	assert.Equal(t, v, true)
+
+	_, err = p.Probe("timeout", "int")
+	assert.Error(t, err, "probe timeout as int error: bind timeout error: strconv.ParseInt: parsing \"5s\": invalid syntax")
+
+	_, err = p.Probe("port", "bool")
+	assert.Error(t, err, "probe port as bool error: bind port error: strconv.ParseBool: parsing \"8080\": invalid syntax")
+
+	_, err = p.Probe("missing", "string")
+	assert.Error(t, err, "probe missing as string error: bind missing error: property \"missing\": not exist")
+
+	_, err = p.Probe("port", "complex")
+	assert.Error(t, err, "probe port error: unsupported type \"complex\"")
 }
 
 //func TestProperties_Has(t *testing.T) {
@@ -19,7 +19,9 @@ package conf
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 
+	"github.com/limpo1989/go-spring/conf/internal"
 	"github.com/spf13/cast"
 )
 
@@ -59,3 +61,45 @@ func flatten(key string, val interface{}, result map[string]string) {
 		result[key] = cast.ToString(val)
 	}
 }
+
+// ToMap converts the properties into a nested map, it's the reverse of Flatten.
+// Keys like a.b become nested maps and keys like a[0] become []interface{},
+// missing indexes are filled with nil. Values are always strings, and empty
+// maps or arrays, which are flattened into empty values, stay empty strings.
+// Keys never collide on types, because Properties rejects a key that wants a
+// different type from the existing one, e.g. a.b when a is a value.
+func (p *Properties) ToMap() map[string]interface{} {
+	ret := make(map[string]interface{})
+	for _, key := range p.Keys() {
+		path, err := internal.SplitPath(key)
+		if err != nil {
+			continue
+		}
+		ret = unflatten(ret, path, p.Get(key)).(map[string]interface{})
+	}
+	return ret
+}
+
+// unflatten puts the value into the node by the path, returns the new node.
+func unflatten(node interface{}, path []internal.Path, val string) interface{} {
+	if len(path) == 0 {
+		return val
+	}
+	switch e := path[0]; e.Type {
+	case internal.PathTypeIndex:
+		s, _ := node.([]interface{})
+		i, _ := strconv.Atoi(e.Elem)
+		for len(s) <= i {
+			s = append(s, nil)
+		}
+		s[i] = unflatten(s[i], path[1:], val)
+		return s
+	default:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			m = make(map[string]interface{})
+		}
+		m[e.Elem] = unflatten(m[e.Elem], path[1:], val)
+		return m
+	}
+}
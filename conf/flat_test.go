@@ -81,3 +81,36 @@ func TestFlatten(t *testing.T) {
 	}
 	assert.Equal(t, m, expect)
 }
+
+func TestProperties_ToMap(t *testing.T) {
+
+	m := map[string]interface{}{
+		"name": "app",
+		"server": map[string]interface{}{
+			"host":  "localhost",
+			"ports": []interface{}{"80", "443"},
+			"routes": []interface{}{
+				map[string]interface{}{"path": "/a", "methods": []interface{}{"GET"}},
+				map[string]interface{}{"path": "/b"},
+			},
+		},
+		"matrix": []interface{}{
+			[]interface{}{"1", "2"},
+			[]interface{}{"3"},
+		},
+		"empty": "",
+	}
+
+	p := Map(m)
+	assert.Equal(t, p.ToMap(), m)
+
+	// round trip
+	assert.Equal(t, Flatten(p.ToMap()), Flatten(m))
+
+	p = New()
+	_ = p.Set("a[2]", "c")
+	_ = p.Set("a[0]", "a")
+	assert.Equal(t, p.ToMap(), map[string]interface{}{
+		"a": []interface{}{"a", nil, "c"},
+	})
+}
@@ -18,6 +18,8 @@ package internal
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/limpo1989/go-spring/internal/utils"
 )
@@ -96,7 +98,8 @@ func (s *Storage) Keys() []string {
 	return utils.SortedKeys(s.data)
 }
 
-// SubKeys returns the sub keys of the key item.
+// SubKeys returns the sub keys of the key item, the names of a map are sorted
+// alphabetically and the indexes of an array are sorted numerically.
 func (s *Storage) SubKeys(key string) ([]string, error) {
 	path, err := SplitPath(key)
 	if err != nil {
@@ -118,6 +121,13 @@ func (s *Storage) SubKeys(key string) ([]string, error) {
 	}
 	m := tree.data.(map[string]*treeNode)
 	keys := utils.SortedKeys(m)
+	if tree.node == nodeTypeArray {
+		sort.Slice(keys, func(i, j int) bool {
+			a, _ := strconv.Atoi(keys[i])
+			b, _ := strconv.Atoi(keys[j])
+			return a < b
+		})
+	}
 	return keys, nil
 }
 
@@ -171,6 +181,32 @@ func (s *Storage) Set(key, val string) error {
 	return nil
 }
 
+// Remove removes the key and its value, and the maps and arrays left empty by
+// the removal. It does nothing when the key has no value.
+func (s *Storage) Remove(key string) {
+	if _, ok := s.data[key]; !ok {
+		return
+	}
+	delete(s.data, key)
+	if path, err := SplitPath(key); err == nil {
+		removeNode(s.tree, path)
+	}
+}
+
+// removeNode removes the node of the path under the tree, and returns whether
+// the tree is left empty.
+func removeNode(tree *treeNode, path []Path) bool {
+	m := tree.data.(map[string]*treeNode)
+	if len(path) == 1 {
+		delete(m, path[0].Elem)
+	} else if v, ok := m[path[0].Elem]; ok && (v.node == nodeTypeMap || v.node == nodeTypeArray) {
+		if removeNode(v, path[1:]) {
+			delete(m, path[0].Elem)
+		}
+	}
+	return len(m) == 0
+}
+
 func (s *Storage) merge(key, val string) (*treeNode, error) {
 	path, err := SplitPath(key)
 	if err != nil {
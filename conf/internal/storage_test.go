@@ -17,6 +17,7 @@
 package internal
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/limpo1989/go-spring/internal/utils/assert"
@@ -236,3 +237,43 @@ func TestStorage(t *testing.T) {
 	})
 
 }
+
+func TestStorage_SubKeys(t *testing.T) {
+	s := NewStorage()
+	for i := 10; i >= 0; i-- {
+		err := s.Set(fmt.Sprintf("a[%d]", i), "x")
+		assert.Nil(t, err)
+		err = s.Set(fmt.Sprintf("m.k%d", i), "x")
+		assert.Nil(t, err)
+	}
+	for n := 0; n < 10; n++ {
+		subKeys, err := s.SubKeys("a")
+		assert.Nil(t, err)
+		assert.Equal(t, subKeys, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10"})
+		subKeys, err = s.SubKeys("m")
+		assert.Nil(t, err)
+		assert.Equal(t, subKeys, []string{"k0", "k1", "k10", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9"})
+	}
+}
+
+func TestStorage_Remove(t *testing.T) {
+	s := NewStorage()
+	for _, key := range []string{"a.b", "a.c[0]", "a.c[1]", "d"} {
+		err := s.Set(key, "x")
+		assert.Nil(t, err)
+	}
+
+	s.Remove("a.c[1]")
+	assert.Equal(t, s.Keys(), []string{"a.b", "a.c[0]", "d"})
+	assert.False(t, s.Has("a.c[1]"))
+
+	// the emptied list and map are removed too.
+	s.Remove("a.c[0]")
+	s.Remove("a.b")
+	assert.False(t, s.Has("a"))
+	err := s.Set("a", "y")
+	assert.Nil(t, err)
+
+	s.Remove("none")
+	assert.Equal(t, s.Keys(), []string{"a", "d"})
+}
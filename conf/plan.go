@@ -0,0 +1,170 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// BindEntry describes what a field would be bound to.
+type BindEntry struct {
+	Path        string // path of the field, such as Config.Server.Port
+	Key         string // key the value comes from, the candidate or alias used
+	Value       string // resolved value, empty for lists and maps
+	UsedDefault bool   // whether the value comes from the default of the tag
+}
+
+// bindPlan is the entries of the fields being planned, and the errors of the
+// missing keys, which are collected instead of stopping at the first one.
+type bindPlan struct {
+	entries []BindEntry
+	missing []error
+}
+
+// BindPlan walks the value that i points to like Bind, but doesn't set it, and
+// returns the key and resolved value each leaf field would be bound to, which
+// helps to find out why a field gets an unexpected value. Structs are walked
+// into, other values including slices, maps and Dynamic values are leaves. It
+// has no side effects, e.g. no warnings on deprecated names are logged, and
+// the error lists all the missing keys along with the entries of the others.
+func (p *Properties) BindPlan(i interface{}, args ...BindArg) ([]BindEntry, error) {
+
+	t := reflect.TypeOf(i)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, errors.New("i should be a ptr")
+	}
+	t = t.Elem()
+
+	typeName := t.Name()
+	if typeName == "" { // primitive type has no name
+		typeName = t.String()
+	}
+
+	param, err := bindParamOf(args)
+	if err != nil {
+		return nil, err
+	}
+	param.Path = typeName
+
+	var plan bindPlan
+	if err = planValue(p, t, param, &plan); err != nil {
+		return nil, err
+	}
+	if len(plan.missing) > 0 {
+		return plan.entries, errors.Join(plan.missing...)
+	}
+	return plan.entries, nil
+}
+
+// planValue appends the entry of the value to plan, or the entries of its
+// fields when it's a struct.
+func planValue(p *Properties, t reflect.Type, param BindParam, plan *bindPlan) error {
+
+	// a Dynamic value is a leaf of its value type.
+	dynamic := false
+	if d, ok := reflect.New(t).Interface().(dynamicValue); ok {
+		t, dynamic = d.valueType(), true
+	}
+
+	if !dynamic && t.Kind() == reflect.Struct && converters[t] == nil && param.Converter == "" && !isTextUnmarshaler(t) {
+		return planStruct(p, t, param, plan)
+	}
+
+	entry := BindEntry{Path: param.Path}
+	entry.Key, entry.UsedDefault = sourceKey(p, param)
+
+	// lists and maps have no value of their own.
+	if k := t.Kind(); (k == reflect.Slice || k == reflect.Map) && !entry.UsedDefault {
+		if keys, err := p.storage.SubKeys(entry.Key); err == nil && len(keys) > 0 {
+			plan.entries = append(plan.entries, entry)
+			return nil
+		}
+	}
+
+	// resolves the key found above only, as the candidates and aliases are
+	// tried already, and an alias would log a deprecation warning.
+	if !entry.UsedDefault && p.storage.Has(entry.Key) {
+		param.Key, param.Candidates, param.Aliases = entry.Key, nil, nil
+	}
+	val, err := resolve(p, param)
+	if err != nil && !isZeroOnMissing(p, param, err) {
+		err = fmt.Errorf("bind %s error: %w", param.Path, err)
+		if errors.Is(err, errNotExist) {
+			plan.missing = append(plan.missing, err)
+			return nil
+		}
+		return err
+	}
+	entry.Value = val
+	plan.entries = append(plan.entries, entry)
+	return nil
+}
+
+// planStruct appends the entries of the struct fields to plan.
+func planStruct(p *Properties, t reflect.Type, param BindParam, plan *bindPlan) error {
+	for _, f := range structPlanOf(t) {
+		subParam := BindParam{
+			Key:           param.Key,
+			Path:          param.Path + "." + f.name,
+			ZeroOnMissing: param.ZeroOnMissing,
+			Lenient:       param.Lenient,
+		}
+		switch f.kind {
+		case fieldTagged:
+			if f.tagErr != nil {
+				return fmt.Errorf("bind %s error: %w", param.Path, f.tagErr)
+			}
+			subParam.bindParsedTag(f.tag, f.validate)
+		case fieldEmbed:
+			if err := planStruct(p, f.typ, subParam, plan); err != nil {
+				return err
+			}
+			continue
+		case fieldValue:
+			if subParam.Key == "" {
+				subParam.Key = f.key
+			} else {
+				subParam.Key = subParam.Key + "." + f.key
+			}
+		}
+		if err := planValue(p, f.typ, subParam, plan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sourceKey returns the key which resolve takes the value from, and whether
+// the default of the tag is used instead.
+func sourceKey(p *Properties, param BindParam) (string, bool) {
+	if p.storage.Has(param.Key) {
+		return param.Key, false
+	}
+	for _, key := range param.Candidates {
+		if p.storage.Has(key) {
+			return key, false
+		}
+	}
+	for _, alias := range param.Aliases {
+		if p.storage.Has(alias) {
+			return alias, false
+		}
+	}
+	return param.Key, param.Tag.HasDef
+}
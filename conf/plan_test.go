@@ -0,0 +1,98 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/limpo1989/go-spring/internal/log"
+	"github.com/limpo1989/go-spring/internal/utils/assert"
+)
+
+func TestBindPlan(t *testing.T) {
+
+	type Server struct {
+		Host  string   `value:"${host}"`
+		Port  int      `value:"${port:=8080}"`
+		Paths []string `value:"${paths}"`
+	}
+
+	type Config struct {
+		Server  Server `value:"${server}"`
+		Name    string `value:"${name|app.name}"`
+		Timeout string `value:"${timeout}" alias:"deadline"`
+		Debug   bool
+		Level   Dynamic[string]   `value:"${level:=info}"`
+		Tags    Dynamic[[]string] `value:"${tags}"`
+	}
+
+	// planning logs no warnings on deprecated names.
+	var buf bytes.Buffer
+	fn := deprecationLogger
+	t.Cleanup(func() { deprecationLogger = fn })
+	deprecationLogger = func(p *Properties) *log.Logger {
+		return slog.New(slog.NewTextHandler(&buf, nil))
+	}
+
+	p := Map(map[string]interface{}{
+		"server": map[string]interface{}{
+			"host":  "localhost",
+			"paths": []string{"/a", "/b"},
+		},
+		"app.name": "demo",
+		"deadline": "${server.host}:3s",
+		"Debug":    "true",
+		"tags":     []string{"a", "b"},
+	})
+
+	var c Config
+	plan, err := p.BindPlan(&c)
+	assert.Nil(t, err)
+	assert.Equal(t, plan, []BindEntry{
+		{Path: "Config.Server.Host", Key: "server.host", Value: "localhost"},
+		{Path: "Config.Server.Port", Key: "server.port", Value: "8080", UsedDefault: true},
+		{Path: "Config.Server.Paths", Key: "server.paths"},
+		{Path: "Config.Name", Key: "app.name", Value: "demo"},
+		{Path: "Config.Timeout", Key: "deadline", Value: "localhost:3s"},
+		{Path: "Config.Debug", Key: "Debug", Value: "true"},
+		{Path: "Config.Level", Key: "level", Value: "info", UsedDefault: true},
+		{Path: "Config.Tags", Key: "tags"},
+	})
+	assert.Equal(t, buf.String(), "")
+	assert.Equal(t, c.Level.Get(), "")
+
+	// all the missing keys are reported, with the entries of the others.
+	plan, err = Map(map[string]interface{}{
+		"name":  "demo",
+		"Debug": "false",
+	}).BindPlan(&c)
+	assert.Error(t, err, "bind Config.Server.Host error: property \"server.host\": not exist\n"+
+		"bind Config.Server.Paths error: property \"server.paths\": not exist\n"+
+		"bind Config.Timeout error: property \"timeout\": not exist\n"+
+		"bind Config.Tags error: property \"tags\": not exist")
+	assert.Equal(t, plan, []BindEntry{
+		{Path: "Config.Server.Port", Key: "server.port", Value: "8080", UsedDefault: true},
+		{Path: "Config.Name", Key: "name", Value: "demo"},
+		{Path: "Config.Debug", Key: "Debug", Value: "false"},
+		{Path: "Config.Level", Key: "level", Value: "info", UsedDefault: true},
+	})
+
+	_, err = p.BindPlan(c.Name)
+	assert.Error(t, err, "i should be a ptr")
+}
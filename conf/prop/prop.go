@@ -16,7 +16,11 @@
 
 package prop
 
-import "github.com/magiconair/properties"
+import (
+	"strings"
+
+	"github.com/magiconair/properties"
+)
 
 // Read parses []byte in the properties format into map.
 func Read(b []byte) (map[string]interface{}, error) {
@@ -31,3 +35,27 @@ func Read(b []byte) (map[string]interface{}, error) {
 	}
 	return ret, nil
 }
+
+// ReadEnv parses []byte in the .env format into map, it's the properties
+// format with optional `export` prefixes, values are trimmed and may be quoted.
+func ReadEnv(b []byte) (map[string]interface{}, error) {
+	lines := strings.Split(string(b), "\n")
+	for i, line := range lines {
+		if s := strings.TrimLeft(line, " \t"); strings.HasPrefix(s, "export ") {
+			lines[i] = strings.TrimPrefix(s, "export ")
+		}
+	}
+	m, err := Read([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]interface{})
+	for k, v := range m {
+		s := strings.TrimSpace(v.(string))
+		if n := len(s); n >= 2 && (s[0] == '"' || s[0] == '\'') && s[n-1] == s[0] {
+			s = s[1 : n-1]
+		}
+		ret[k] = s
+	}
+	return ret, nil
+}
@@ -46,6 +46,16 @@ func TestRead(t *testing.T) {
 		})
 	})
 
+	t.Run("trailing spaces", func(t *testing.T) {
+		r, err := Read([]byte("string = hello  \n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, r, map[string]interface{}{
+			"string": "hello  ",
+		})
+	})
+
 	t.Run("map", func(t *testing.T) {
 		r, err := Read([]byte(`
 			map.bool=false
@@ -117,3 +127,24 @@ func TestRead(t *testing.T) {
 		})
 	})
 }
+
+func TestReadEnv(t *testing.T) {
+	r, err := ReadEnv([]byte(`
+		# comment
+		export HOST=localhost
+		NAME="hello world"
+		QUOTE='a "b" c'
+		HALF="abc
+		URL=${HOST}:8080
+		PORT=8080  
+	`))
+	assert.Nil(t, err)
+	assert.Equal(t, r, map[string]interface{}{
+		"HOST":  "localhost",
+		"NAME":  "hello world",
+		"QUOTE": `a "b" c`,
+		"HALF":  `"abc`,
+		"URL":   "${HOST}:8080",
+		"PORT":  "8080",
+	})
+}
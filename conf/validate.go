@@ -17,14 +17,27 @@
 package conf
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/antonmedv/expr"
+	"github.com/limpo1989/go-spring/internal/utils"
 )
 
 var validators = map[string]Validator{
-	"expr": &exprValidator{},
+	"expr":   &exprValidator{},
+	"scale":  &scaleValidator{},
+	"min":    &minValidator{},
+	"max":    &maxValidator{},
+	"len":    &lenValidator{},
+	"oneof":  &oneofValidator{},
+	"regexp": &regexpValidator{},
+	"dive":   &diveValidator{},
 }
 
 // Validator is interface for validating a field.
@@ -32,6 +45,25 @@ type Validator interface {
 	Field(tag string, i interface{}) error
 }
 
+// FieldsValidator is a Validator which can access the sibling fields of the
+// field being validated, fields is nil when it's not a struct field.
+type FieldsValidator interface {
+	Validator
+	FieldWith(tag string, i interface{}, fields map[string]interface{}) error
+}
+
+// validateAll makes Validate run all validators of the tag and return all
+// the failures, instead of stopping at the first one.
+var validateAll = false
+
+// SetValidateAll sets whether Validate runs all validators of the tag and
+// returns all the failures together, by default it stops at the first one.
+// It also makes Bind report the validation failures of all struct fields as
+// ValidationErrors.
+func SetValidateAll(enable bool) {
+	validateAll = enable
+}
+
 // Register registers a Validator with tag name.
 func Register(name string, v Validator) {
 	validators[name] = v
@@ -39,21 +71,142 @@ func Register(name string, v Validator) {
 
 // Validate validates a single variable.
 func Validate(tag reflect.StructTag, i interface{}) error {
-	for name, v := range validators {
-		if s, ok := tag.Lookup(name); ok {
-			if err := v.Field(s, i); err != nil {
+	return validateFields(tag, i, nil)
+}
+
+// ValidationError is the failure of a value on a validator, which can be found
+// by errors.As from the errors of Validate and Bind.
+type ValidationError struct {
+	Path  string      // path of the field, empty for a single variable
+	Rule  string      // the failed rule, like min:"1"
+	Value interface{} // the value failing the rule
+	Msg   string      // the message of the failure
+}
+
+// newValidationError returns a ValidationError of the validator tag.
+func newValidationError(name, tag string, i interface{}, msg string) *ValidationError {
+	return &ValidationError{Rule: fmt.Sprintf("%s:%q", name, tag), Value: i, Msg: msg}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Msg
+}
+
+// ValidationErrors is the failures of several validators or fields, they are
+// collected instead of stopping at the first one when SetValidateAll is enabled.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	var sb strings.Builder
+	for i, err := range e {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		if err.Path != "" {
+			sb.WriteString("validate " + err.Path + " error: ")
+		}
+		sb.WriteString(err.Msg)
+	}
+	return sb.String()
+}
+
+// withPath returns the validation failures with the path prefixed, and other
+// errors as is.
+func withPath(err error, path string) error {
+	switch e := err.(type) {
+	case *ValidationError:
+		r := *e
+		r.Path = path + e.Path
+		return &r
+	case ValidationErrors:
+		r := make(ValidationErrors, len(e))
+		for i := range e {
+			r[i] = withPath(e[i], path).(*ValidationError)
+		}
+		return r
+	}
+	return err
+}
+
+// asValidationErrors returns the validation failures that err wraps.
+func asValidationErrors(err error) (ValidationErrors, bool) {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+	var e *ValidationError
+	if errors.As(err, &e) {
+		return ValidationErrors{e}, true
+	}
+	return nil, false
+}
+
+// validateFields validates a single variable with its sibling fields.
+func validateFields(tag reflect.StructTag, i interface{}, fields map[string]interface{}) error {
+	var errs []error
+	for _, name := range utils.SortedKeys(validators) {
+		s, ok := tag.Lookup(name)
+		if !ok {
+			continue
+		}
+		var err error
+		if fv, ok := validators[name].(FieldsValidator); ok && fields != nil {
+			err = fv.FieldWith(s, i, fields)
+		} else {
+			err = validators[name].Field(s, i)
+		}
+		if err != nil {
+			if !validateAll {
 				return err
 			}
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	// failures of the built-in validators are collected as ValidationErrors.
+	var failures ValidationErrors
+	for _, err := range errs {
+		switch e := err.(type) {
+		case *ValidationError:
+			failures = append(failures, e)
+		case ValidationErrors:
+			failures = append(failures, e...)
+		default:
+			return errors.Join(errs...)
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return failures
+}
+
+// hasFieldsValidator returns whether the tag has a FieldsValidator.
+func hasFieldsValidator(tag reflect.StructTag) bool {
+	for name, v := range validators {
+		if _, ok := v.(FieldsValidator); ok {
+			if _, ok = tag.Lookup(name); ok {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 type exprValidator struct{}
 
 // Field validates a single variable.
 func (d exprValidator) Field(tag string, i interface{}) error {
-	r, err := expr.Eval(tag, map[string]interface{}{"$": i})
+	return d.FieldWith(tag, i, nil)
+}
+
+// FieldWith validates a single variable, the sibling fields are accessed by
+// `fields`, e.g. `expr:"$>fields.Start"`.
+func (d exprValidator) FieldWith(tag string, i interface{}, fields map[string]interface{}) error {
+	env := map[string]interface{}{"$": i}
+	if fields != nil {
+		env["fields"] = fields
+	}
+	r, err := expr.Eval(tag, env)
 	if err != nil {
 		return fmt.Errorf("eval %q returns: %w", tag, err)
 	}
@@ -63,7 +216,225 @@ func (d exprValidator) Field(tag string, i interface{}) error {
 	}
 
 	if !b {
-		return fmt.Errorf("validate failed on %q for value %v", tag, i)
+		return newValidationError("expr", tag, i, fmt.Sprintf("validate failed on %q for value %v", tag, i))
 	}
 	return nil
 }
+
+type scaleValidator struct{}
+
+// Field validates that a float has at most `scale` decimal places.
+func (d scaleValidator) Field(tag string, i interface{}) error {
+	scale, err := strconv.Atoi(tag)
+	if err != nil || scale < 0 {
+		return fmt.Errorf("invalid scale %q", tag)
+	}
+	var s string
+	switch f := i.(type) {
+	case float32:
+		s = strconv.FormatFloat(float64(f), 'f', -1, 32)
+	case float64:
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	default:
+		return fmt.Errorf("scale %q can't apply to %T", tag, i)
+	}
+	if n := strings.IndexByte(s, '.'); n >= 0 && len(s)-n-1 > scale {
+		return newValidationError("scale", tag, i, fmt.Sprintf("validate failed on scale %q for value %v", tag, i))
+	}
+	return nil
+}
+
+// compareNumber compares the number i with the number in the tag, it returns
+// -1 if i is less than, 0 if equal to, and +1 if greater than the tag.
+func compareNumber(name, tag string, i interface{}) (int, error) {
+	v := reflect.ValueOf(i)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(tag, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, tag)
+		}
+		return compare(v.Int() < n, v.Int() > n), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(tag, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, tag)
+		}
+		return compare(v.Uint() < n, v.Uint() > n), nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(tag, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s %q", name, tag)
+		}
+		return compare(v.Float() < n, v.Float() > n), nil
+	default:
+		return 0, fmt.Errorf("%s %q can't apply to %T", name, tag, i)
+	}
+}
+
+func compare(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type minValidator struct{}
+
+// Field validates that a number isn't less than the tag.
+func (d minValidator) Field(tag string, i interface{}) error {
+	r, err := compareNumber("min", tag, i)
+	if err != nil {
+		return err
+	}
+	if r < 0 {
+		return newValidationError("min", tag, i, fmt.Sprintf("validate failed on min %q for value %v", tag, i))
+	}
+	return nil
+}
+
+type maxValidator struct{}
+
+// Field validates that a number isn't greater than the tag.
+func (d maxValidator) Field(tag string, i interface{}) error {
+	r, err := compareNumber("max", tag, i)
+	if err != nil {
+		return err
+	}
+	if r > 0 {
+		return newValidationError("max", tag, i, fmt.Sprintf("validate failed on max %q for value %v", tag, i))
+	}
+	return nil
+}
+
+type lenValidator struct{}
+
+// Field validates that the length of a string, slice or map equals to the tag.
+func (d lenValidator) Field(tag string, i interface{}) error {
+	n, err := strconv.Atoi(tag)
+	if err != nil {
+		return fmt.Errorf("invalid len %q", tag)
+	}
+	v := reflect.ValueOf(i)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		if v.Len() != n {
+			return newValidationError("len", tag, i, fmt.Sprintf("validate failed on len %q for value %v", tag, i))
+		}
+		return nil
+	default:
+		return fmt.Errorf("len %q can't apply to %T", tag, i)
+	}
+}
+
+type oneofValidator struct{}
+
+// Field validates that a string or number is one of the space separated
+// values in the tag.
+func (d oneofValidator) Field(tag string, i interface{}) error {
+	switch reflect.ValueOf(i).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+		return fmt.Errorf("oneof %q can't apply to %T", tag, i)
+	}
+	s := fmt.Sprint(i)
+	for _, v := range strings.Fields(tag) {
+		if v == s {
+			return nil
+		}
+	}
+	return newValidationError("oneof", tag, i, fmt.Sprintf("validate failed on oneof %q for value %v", tag, i))
+}
+
+type regexpValidator struct {
+	cache sync.Map // compiled patterns by tag
+}
+
+// Field validates that a string matches the regular expression in the tag.
+func (d *regexpValidator) Field(tag string, i interface{}) error {
+	s, ok := i.(string)
+	if !ok {
+		return fmt.Errorf("regexp %q can't apply to %T", tag, i)
+	}
+	var r *regexp.Regexp
+	if v, ok := d.cache.Load(tag); ok {
+		r = v.(*regexp.Regexp)
+	} else {
+		var err error
+		if r, err = regexp.Compile(tag); err != nil {
+			return fmt.Errorf("invalid regexp %q: %w", tag, err)
+		}
+		d.cache.Store(tag, r)
+	}
+	if !r.MatchString(s) {
+		return newValidationError("regexp", tag, i, fmt.Sprintf("validate failed on regexp %q for value %v", tag, i))
+	}
+	return nil
+}
+
+type diveValidator struct{}
+
+// Field validates each element of a slice or each value of a map. The tag is
+// the validator tags of the elements, like `dive:"min:\"1\" max:\"9\""`, or an
+// expr like `dive:"$>0"` when it has no registered validator.
+func (d diveValidator) Field(tag string, i interface{}) error {
+	validate := func(e interface{}) error {
+		return exprValidator{}.Field(tag, e)
+	}
+	if hasValidator(reflect.StructTag(tag)) {
+		validate = func(e interface{}) error {
+			return Validate(reflect.StructTag(tag), e)
+		}
+	}
+	v := reflect.ValueOf(i)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for j := 0; j < v.Len(); j++ {
+			if err := validate(v.Index(j).Interface()); err != nil {
+				return diveError(fmt.Sprintf("[%d]", j), err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validate(iter.Value().Interface()); err != nil {
+				return diveError(fmt.Sprintf("[%v]", iter.Key().Interface()), err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("dive %q can't apply to %T", tag, i)
+	}
+}
+
+// hasValidator returns whether the tag has any registered validator.
+func hasValidator(tag reflect.StructTag) bool {
+	for name := range validators {
+		if _, ok := tag.Lookup(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// diveError returns the error of the element at the index, the failures keep
+// being ValidationError with the index as their path.
+func diveError(index string, err error) error {
+	switch e := err.(type) {
+	case *ValidationError:
+		r := withPath(e, index).(*ValidationError)
+		r.Msg = fmt.Sprintf("dive %s error: %s", index, e.Msg)
+		return r
+	case ValidationErrors:
+		r := make(ValidationErrors, len(e))
+		for i := range e {
+			r[i] = diveError(index, e[i]).(*ValidationError)
+		}
+		return r
+	}
+	return fmt.Errorf("dive %s error: %w", index, err)
+}
@@ -17,6 +17,8 @@
 package conf
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/limpo1989/go-spring/internal/utils/assert"
@@ -57,3 +59,200 @@ func TestField(t *testing.T) {
 	err = Validate("expr:\"$<3\"", "abc")
 	assert.Error(t, err, "invalid operation\\: string \\< int \\(1:2\\)")
 }
+
+func TestScale(t *testing.T) {
+
+	err := Validate("scale:\"2\"", 3.14)
+	assert.Nil(t, err)
+
+	err = Validate("scale:\"2\"", float64(12))
+	assert.Nil(t, err)
+
+	err = Validate("scale:\"2\"", float32(0.5))
+	assert.Nil(t, err)
+
+	err = Validate("scale:\"2\"", 3.141)
+	assert.Error(t, err, "validate failed on scale \"2\" for value 3.141")
+
+	err = Validate("scale:\"0\"", 1.5)
+	assert.Error(t, err, "validate failed on scale \"0\" for value 1.5")
+
+	err = Validate("scale:\"x\"", 1.5)
+	assert.Error(t, err, "invalid scale \"x\"")
+
+	err = Validate("scale:\"2\"", 3)
+	assert.Error(t, err, "scale \"2\" can't apply to int")
+}
+
+func TestMinMax(t *testing.T) {
+
+	err := Validate("min:\"1\" max:\"65535\"", int64(1))
+	assert.Nil(t, err)
+
+	err = Validate("min:\"1\" max:\"65535\"", int64(65535))
+	assert.Nil(t, err)
+
+	err = Validate("min:\"1\"", int64(0))
+	assert.Error(t, err, "validate failed on min \"1\" for value 0")
+
+	err = Validate("max:\"65535\"", uint64(65536))
+	assert.Error(t, err, "validate failed on max \"65535\" for value 65536")
+
+	err = Validate("min:\"0.5\"", 0.5)
+	assert.Nil(t, err)
+
+	err = Validate("max:\"0.5\"", 0.51)
+	assert.Error(t, err, "validate failed on max \"0.5\" for value 0.51")
+
+	err = Validate("min:\"a\"", 3)
+	assert.Error(t, err, "invalid min \"a\"")
+
+	err = Validate("max:\"3\"", "abc")
+	assert.Error(t, err, "max \"3\" can't apply to string")
+}
+
+func TestLen(t *testing.T) {
+
+	err := Validate("len:\"3\"", "abc")
+	assert.Nil(t, err)
+
+	err = Validate("len:\"3\"", "ab")
+	assert.Error(t, err, "validate failed on len \"3\" for value ab")
+
+	err = Validate("len:\"2\"", []string{"a", "b"})
+	assert.Nil(t, err)
+
+	err = Validate("len:\"0\"", map[string]int{"a": 1})
+	assert.Error(t, err, "validate failed on len \"0\" for value map\\[a:1\\]")
+
+	err = Validate("len:\"1\"", 1)
+	assert.Error(t, err, "len \"1\" can't apply to int")
+}
+
+func TestOneof(t *testing.T) {
+
+	err := Validate("oneof:\"debug info warn\"", "info")
+	assert.Nil(t, err)
+
+	err = Validate("oneof:\"debug info warn\"", "error")
+	assert.Error(t, err, "validate failed on oneof \"debug info warn\" for value error")
+
+	err = Validate("oneof:\"1 2 3\"", int64(3))
+	assert.Nil(t, err)
+
+	err = Validate("oneof:\"1 2 3\"", int64(4))
+	assert.Error(t, err, "validate failed on oneof \"1 2 3\" for value 4")
+
+	err = Validate("oneof:\"a\"", []string{"a"})
+	assert.Error(t, err, "oneof \"a\" can't apply to \\[\\]string")
+}
+
+func TestRegexp(t *testing.T) {
+
+	tag := reflect.StructTag(`regexp:"^[a-z][a-z0-9_]*$"`)
+
+	err := Validate(tag, "user_01")
+	assert.Nil(t, err)
+
+	err = Validate(tag, "01_user")
+	assert.Error(t, err, "validate failed on regexp \"\\^\\[a-z\\]\\[a-z0-9_\\]\\*\\$\" for value 01_user")
+
+	err = Validate(`regexp:"[a-z"`, "a")
+	assert.Error(t, err, "invalid regexp \"\\[a-z\": error parsing regexp")
+
+	err = Validate(tag, 1)
+	assert.Error(t, err, "regexp .* can't apply to int")
+
+	var s struct {
+		Name string `value:"${name}" regexp:"^[a-z]+$"`
+	}
+	err = Map(map[string]interface{}{"name": "Abc"}).Bind(&s)
+	assert.Error(t, err, "validate .*Name error: validate failed on regexp")
+}
+
+func TestDive(t *testing.T) {
+
+	err := Validate(`dive:"$>0"`, []int{1, 2, 3})
+	assert.Nil(t, err)
+
+	err = Validate(`dive:"$>0"`, []int{1, 0, 3})
+	assert.Error(t, err, "dive \\[1\\] error: validate failed on \"\\$>0\" for value 0")
+
+	err = Validate(`dive:"expr:\"$>0\""`, []int{1, 0, 3})
+	assert.Error(t, err, "dive \\[1\\] error: validate failed on \"\\$>0\" for value 0")
+
+	// an expr containing :" is still an expr.
+	err = Validate(`dive:"$ != \"a:\""`, []string{"b", "a:"})
+	assert.Error(t, err, "dive \\[1\\] error: validate failed on")
+
+	err = Validate(`dive:"expr:\"$ != \\\"a:\\\"\""`, []string{"b", "a:"})
+	assert.Error(t, err, "dive \\[1\\] error: validate failed on")
+
+	err = Validate(`dive:"min:\"1\" max:\"9\""`, map[string]int64{"a": 1, "b": 9})
+	assert.Nil(t, err)
+
+	err = Validate(`dive:"max:\"9\""`, map[string]int64{"a": 10})
+	assert.Error(t, err, "dive \\[a\\] error: validate failed on max \"9\" for value 10")
+
+	err = Validate(`dive:"$>0"`, 1)
+	assert.Error(t, err, "dive \"\\$>0\" can't apply to int")
+}
+
+func TestValidateAll(t *testing.T) {
+
+	tag := reflect.StructTag("min:\"1\" oneof:\"1 2 3\"")
+
+	err := Validate(tag, int64(0))
+	assert.Error(t, err, "^validate failed on min \"1\" for value 0$")
+
+	SetValidateAll(true)
+	defer SetValidateAll(false)
+
+	err = Validate(tag, int64(0))
+	assert.Error(t, err, "^validate failed on min \"1\" for value 0\nvalidate failed on oneof \"1 2 3\" for value 0$")
+
+	err = Validate(tag, int64(2))
+	assert.Nil(t, err)
+}
+
+func TestValidationError(t *testing.T) {
+
+	type Config struct {
+		Port  int    `value:"${port}" expr:"$>0"`
+		Sizes []int  `value:"${sizes}" dive:"$>0"`
+		Name  string `value:"${name}" oneof:"a b"`
+	}
+
+	p := Map(map[string]interface{}{
+		"port":  0,
+		"sizes": []int{1, 0},
+		"name":  "c",
+	})
+
+	var c Config
+	err := p.Bind(&c)
+	var e *ValidationError
+	assert.True(t, errors.As(err, &e))
+	assert.Equal(t, e, &ValidationError{
+		Path:  "Config.Port",
+		Rule:  `expr:"$>0"`,
+		Value: int64(0),
+		Msg:   `validate failed on "$>0" for value 0`,
+	})
+
+	SetValidateAll(true)
+	defer SetValidateAll(false)
+
+	err = p.Bind(&c)
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, len(errs), 3)
+	assert.Equal(t, errs[0].Path, "Config.Port")
+	assert.Equal(t, errs[1].Path, "Config.Sizes[1]")
+	assert.Equal(t, errs[1].Rule, `expr:"$>0"`)
+	assert.Equal(t, errs[1].Value, 0)
+	assert.Equal(t, errs[2].Path, "Config.Name")
+	assert.Equal(t, errs[2].Rule, `oneof:"a b"`)
+	assert.Equal(t, errs[2].Value, "c")
+	assert.Error(t, err, "validate Config.Sizes\\[1\\] error: dive \\[1\\] error: validate failed")
+}
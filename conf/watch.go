@@ -0,0 +1,160 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/limpo1989/go-spring/internal/utils"
+)
+
+// Dynamic holds a value that can be read while BindWatch rebinds it, a field
+// of type Dynamic[T] is bound like a field of type T, and the bound value is
+// stored atomically as a whole. Dynamic must not be copied after binding.
+type Dynamic[T any] struct {
+	v atomic.Pointer[T]
+}
+
+// Get returns the bound value, or the zero value before binding.
+func (d *Dynamic[T]) Get() T {
+	if v := d.v.Load(); v != nil {
+		return *v
+	}
+	var zero T
+	return zero
+}
+
+// dynamicValue is implemented by *Dynamic[T].
+type dynamicValue interface {
+	valueType() reflect.Type
+	setValue(v reflect.Value)
+}
+
+func (d *Dynamic[T]) valueType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func (d *Dynamic[T]) setValue(v reflect.Value) {
+	t := v.Interface().(T)
+	d.v.Store(&t)
+}
+
+// BindWatch binds properties to the struct that target points to like Bind,
+// then rebinds a field when the keys it binds are added or changed by Set,
+// Merge or loading. The new value of a field is bound into a temporary value
+// first, so the field is replaced as a whole and never left half bound. When
+// binding or validating the new value fails, the field keeps its old value and
+// the error is reported to onError. The rebinding runs in the goroutine
+// changing the properties, call stop to stop watching.
+//
+// Fields of type Dynamic[T] are replaced atomically, so they can be read by
+// Get in other goroutines. Other fields are replaced without locking,
+// they should only be read in the goroutine changing the properties.
+func BindWatch(p *Properties, target interface{}, onError ...func(error)) (stop func(), err error) {
+
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("target should be a struct pointer")
+	}
+	if err = p.Bind(target); err != nil {
+		return nil, err
+	}
+
+	v = v.Elem()
+	t := v.Type()
+	param := BindParam{Path: t.Name()}
+
+	report := func(err error) {
+		for _, fn := range onError {
+			fn(err)
+		}
+	}
+
+	stop = p.watch(&changeWatcher{
+		prefix: true,
+		fn: func(key, _, _ string) {
+			for _, f := range structPlanOf(t) {
+				if err := rebindField(p, v, f, param, key); err != nil {
+					report(err)
+				}
+			}
+		},
+	})
+	return stop, nil
+}
+
+// rebindField rebinds the field of the struct value if the changed key is
+// one of the keys it binds.
+func rebindField(p *Properties, v reflect.Value, f fieldPlan, param BindParam, key string) error {
+
+	subParam := BindParam{
+		Key:   param.Key,
+		Path:  param.Path + "." + f.name,
+		Owner: v,
+	}
+
+	var keys []string
+	switch f.kind {
+	case fieldTagged:
+		if f.tagErr != nil {
+			return nil
+		}
+		subParam.bindParsedTag(f.tag, f.validate)
+		keys = append(append([]string{subParam.Key}, subParam.Candidates...), subParam.Aliases...)
+	case fieldEmbed:
+		keys = []string{subParam.Key}
+	case fieldValue:
+		if subParam.Key == "" {
+			subParam.Key = f.key
+		} else {
+			subParam.Key = subParam.Key + "." + f.key
+		}
+		keys = []string{subParam.Key}
+	}
+
+	changed := false
+	for _, k := range keys {
+		if isSubKey(key, k) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	fv := v.Field(f.index)
+	if !fv.CanInterface() {
+		fv = utils.PatchValue(fv)
+	}
+	if _, ok := fv.Addr().Interface().(dynamicValue); ok {
+		return BindValue(p, fv, f.typ, subParam, nil)
+	}
+	nv := reflect.New(f.typ).Elem()
+	if f.kind == fieldEmbed {
+		nv.Set(fv)
+		if err := bindStruct(p, nv, f.typ, subParam, nil); err != nil {
+			return err
+		}
+	} else if err := BindValue(p, nv, f.typ, subParam, nil); err != nil {
+		return err
+	}
+	fv.Set(nv)
+	return nil
+}
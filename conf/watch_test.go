@@ -0,0 +1,138 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package conf
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/limpo1989/go-spring/internal/utils/assert"
+)
+
+func TestBindWatch(t *testing.T) {
+
+	type Pool struct {
+		Max int `value:"${pool.max:=10}" min:"1"`
+	}
+
+	type Config struct {
+		Pool
+		Host  string   `value:"${host}"`
+		Port  int      `value:"${port:=8080}" max:"65535"`
+		Hosts []string `value:"${hosts:=}"`
+		Name  string
+	}
+
+	p := Map(map[string]interface{}{
+		"host":  "localhost",
+		"Name":  "app",
+		"hosts": []string{"a", "b"},
+	})
+
+	var c Config
+	var errs []error
+	stop, err := BindWatch(p, &c, func(err error) {
+		errs = append(errs, err)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, c, Config{Pool{10}, "localhost", 8080, []string{"a", "b"}, "app"})
+
+	_ = p.Set("host", "remote")
+	_ = p.Set("Name", "web")
+	assert.Equal(t, c.Host, "remote")
+	assert.Equal(t, c.Name, "web")
+
+	_ = p.Set("hosts[1]", "c")
+	assert.Equal(t, c.Hosts, []string{"a", "c"})
+
+	// adding a key overrides the default value.
+	_ = p.Set("port", 9090)
+	assert.Equal(t, c.Port, 9090)
+	_ = p.Set("port", 9091)
+	assert.Equal(t, c.Port, 9091)
+
+	// the invalid value is reported and the old value is kept.
+	_ = p.Set("port", 70000)
+	assert.Equal(t, c.Port, 9091)
+	assert.Equal(t, len(errs), 1)
+	assert.Error(t, errs[0], "validate Config.Port error: validate failed on max \"65535\" for value 70000")
+
+	_ = p.Set("pool.max", 20)
+	assert.Equal(t, c.Max, 20)
+	_ = p.Set("pool.max", 0)
+	assert.Equal(t, c.Max, 20)
+	assert.Equal(t, len(errs), 2)
+	_ = p.Set("pool.max", 30)
+	assert.Equal(t, c.Max, 30)
+
+	stop()
+	_ = p.Set("host", "other")
+	assert.Equal(t, c.Host, "remote")
+
+	_, err = BindWatch(p, c)
+	assert.Error(t, err, "target should be a struct pointer")
+}
+
+func TestBindWatch_Dynamic(t *testing.T) {
+
+	type Config struct {
+		Host Dynamic[string] `value:"${host}"`
+		Port Dynamic[int]    `value:"${port:=8080}" max:"65535"`
+	}
+
+	p := Map(map[string]interface{}{"host": "localhost"})
+
+	var c Config
+	var errs []error
+	stop, err := BindWatch(p, &c, func(err error) {
+		errs = append(errs, err)
+	})
+	assert.Nil(t, err)
+	defer stop()
+	assert.Equal(t, c.Host.Get(), "localhost")
+	assert.Equal(t, c.Port.Get(), 8080)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = c.Host.Get()
+				_ = c.Port.Get()
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = p.Set("host", fmt.Sprintf("host-%d", i))
+		_ = p.Set("port", 9000+i)
+	}
+	close(done)
+	wg.Wait()
+	assert.Equal(t, c.Host.Get(), "host-99")
+	assert.Equal(t, c.Port.Get(), 9099)
+
+	// the invalid value is reported and the old value is kept.
+	_ = p.Set("port", 70000)
+	assert.Equal(t, c.Port.Get(), 9099)
+	assert.Equal(t, len(errs), 1)
+}
@@ -17,6 +17,8 @@
 package yaml
 
 import (
+	"fmt"
+
 	"gopkg.in/yaml.v2"
 )
 
@@ -25,6 +27,11 @@ func Read(b []byte) (map[string]interface{}, error) {
 	m := make(map[string]interface{})
 	err := yaml.Unmarshal(b, &m)
 	if err != nil {
+		// a scalar or a sequence can't be flattened into properties.
+		var v interface{}
+		if yaml.Unmarshal(b, &v) == nil && v != nil {
+			return nil, fmt.Errorf("yaml root should be a map but got %T: %w", v, err)
+		}
 		return nil, err
 	}
 	return m, nil
@@ -32,6 +32,18 @@ func TestRead(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 
+	t.Run("root", func(t *testing.T) {
+		_, err := Read([]byte("hello"))
+		assert.Error(t, err, "yaml root should be a map but got string")
+
+		_, err = Read([]byte("- 1\n- 2"))
+		assert.Error(t, err, "yaml root should be a map but got \\[\\]interface \\{\\}")
+
+		r, err := Read([]byte("~"))
+		assert.Nil(t, err)
+		assert.Equal(t, len(r), 0)
+	})
+
 	t.Run("basic type", func(t *testing.T) {
 		str := `
 			bool: false
@@ -17,11 +17,11 @@
 package gs
 
 import (
-	"io/ioutil"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/limpo1989/go-spring/conf"
+	"github.com/limpo1989/go-spring/gs/cond"
 )
 
 type Configuration struct {
@@ -51,7 +51,7 @@ func (e *Configuration) Load(props *conf.Properties) error {
 	}
 
 	// 从文件加载的配置
-	if err := e.loadProperties(props); nil != err {
+	if err := e.loadProperties(props, p); nil != err {
 		return err
 	}
 
@@ -59,51 +59,62 @@ func (e *Configuration) Load(props *conf.Properties) error {
 	for _, k := range p.Keys() {
 		props.Set(k, p.Get(k))
 	}
+
+	// 激活的 profile 包含其分组的成员
+	if len(e.ActiveProfiles) > 0 {
+		return props.Set(cond.ProfilesKey, strings.Join(e.ActiveProfiles, ","))
+	}
 	return nil
 }
 
-func (e *Configuration) loadProperties(props *conf.Properties) error {
-	var resources []Resource
+func (e *Configuration) loadProperties(props *conf.Properties, p *conf.Properties) error {
 
+	var filenames []string
 	for _, ext := range e.ConfigExtensions {
-		sources, err := e.loadResource("application" + ext)
-		if err != nil {
-			return err
-		}
-		resources = append(resources, sources...)
+		filenames = append(filenames, "application"+ext)
+	}
+	if err := e.loadFiles(props, filenames); err != nil {
+		return err
+	}
+
+	// profile 分组可以定义在默认配置文件、环境变量或者命令行参数中
+	groups := props.Copy()
+	for _, k := range p.Keys() {
+		_ = groups.Set(k, p.Get(k))
 	}
+	e.ActiveProfiles = cond.ExpandProfiles(e.ActiveProfiles, func(profile string) []string {
+		var members []string
+		_ = groups.Bind(&members, conf.Tag("${"+cond.ProfileGroupPrefix+profile+":=}"))
+		return members
+	})
 
+	filenames = nil
 	for _, profile := range e.ActiveProfiles {
 		for _, ext := range e.ConfigExtensions {
-			sources, err := e.loadResource("application-" + profile + ext)
-			if err != nil {
-				return err
-			}
-			resources = append(resources, sources...)
+			filenames = append(filenames, "application-"+profile+ext)
 		}
 	}
+	return e.loadFiles(props, filenames)
+}
 
-	defer func() {
-		for _, resource := range resources {
-			_ = resource.Close()
-		}
-	}()
+func (e *Configuration) loadFiles(props *conf.Properties, filenames []string) error {
+	var resources []Resource
 
-	for _, resource := range resources {
-		b, err := ioutil.ReadAll(resource)
-		if err != nil {
-			return err
-		}
-		p, err := conf.Bytes(b, filepath.Ext(resource.Name()))
+	for _, filename := range filenames {
+		sources, err := e.loadResource(filename)
 		if err != nil {
 			return err
 		}
-		for _, key := range p.Keys() {
-			props.Set(key, p.Get(key))
-		}
+		resources = append(resources, sources...)
 	}
 
-	return nil
+	defer func() {
+		for _, resource := range resources {
+			_ = resource.Close()
+		}
+	}()
+
+	return LoadResources(props, resources)
 }
 
 func (e *Configuration) loadResource(filename string) ([]Resource, error) {
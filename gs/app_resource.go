@@ -17,9 +17,17 @@
 package gs
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/limpo1989/go-spring/conf"
 )
 
 type Resource interface {
@@ -31,6 +39,27 @@ type ResourceLocator interface {
 	Locate(filename string) ([]Resource, error)
 }
 
+// LoadResources reads the resources in order into props, the format of each
+// one is decided by the extension of its name, and the latter ones override
+// the keys of the former ones. The name of the resource that sets each key is
+// recorded, see conf.Properties.Origin, and the resources aren't closed.
+func LoadResources(props *conf.Properties, resources []Resource) error {
+	for _, resource := range resources {
+		b, err := io.ReadAll(resource)
+		if err != nil {
+			return err
+		}
+		p, err := conf.Bytes(b, filepath.Ext(resource.Name()))
+		if err != nil {
+			return err
+		}
+		for _, key := range p.Keys() {
+			_ = props.SetFrom(key, p.Get(key), resource.Name())
+		}
+	}
+	return nil
+}
+
 // FileResourceLocator locate Resource from file system.
 type FileResourceLocator struct {
 	ConfigLocations []string `value:"${spring.config.locations:=config/}"`
@@ -51,3 +80,134 @@ func (locator *FileResourceLocator) Locate(filename string) ([]Resource, error)
 	}
 	return resources, nil
 }
+
+// LocateProfiles locates the Resource of filename and its profile variants,
+// e.g. application-dev.yaml for application.yaml. The base file comes first
+// and then the profile files in the order of profiles, so the latter can
+// override the former. It returns only the base if no profile file exists.
+func (locator *FileResourceLocator) LocateProfiles(filename string, profiles []string) ([]Resource, error) {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	filenames := []string{filename}
+	for _, profile := range profiles {
+		filenames = append(filenames, base+"-"+profile+ext)
+	}
+	var resources []Resource
+	for _, name := range filenames {
+		sources, err := locator.Locate(name)
+		if err != nil {
+			for _, r := range resources {
+				_ = r.Close()
+			}
+			return nil, err
+		}
+		resources = append(resources, sources...)
+	}
+	return resources, nil
+}
+
+// CompositeResourceLocator locate Resource from its locators in order.
+type CompositeResourceLocator struct {
+	Locators []ResourceLocator
+
+	// FirstFound returns only the resources of the first locator finding any,
+	// otherwise the resources of all locators are concatenated.
+	FirstFound bool
+
+	// CollectErrors goes on locating when a locator fails and returns all the
+	// errors at the end, otherwise it stops at the first error.
+	CollectErrors bool
+}
+
+func (locator *CompositeResourceLocator) Locate(filename string) ([]Resource, error) {
+	var (
+		errs      []error
+		resources []Resource
+	)
+	for _, l := range locator.Locators {
+		sources, err := l.Locate(filename)
+		if err != nil {
+			errs = append(errs, err)
+			if locator.CollectErrors {
+				continue
+			}
+			break
+		}
+		resources = append(resources, sources...)
+		if locator.FirstFound && len(resources) > 0 {
+			break
+		}
+	}
+	if len(errs) > 0 {
+		for _, r := range resources {
+			_ = r.Close()
+		}
+		return nil, errors.Join(errs...)
+	}
+	return resources, nil
+}
+
+// HTTPResourceLocator locate Resource from http servers, a missing file is
+// responded with 404 and skipped.
+type HTTPResourceLocator struct {
+	ConfigURLs  []string      `value:"${spring.config.urls:=}"`
+	Timeout     time.Duration `value:"${spring.config.timeout:=5s}"`
+	BearerToken string        `value:"${spring.config.token:=}"`
+}
+
+func (locator *HTTPResourceLocator) Locate(filename string) ([]Resource, error) {
+	client := &http.Client{Timeout: locator.Timeout}
+	var resources []Resource
+	for _, location := range locator.ConfigURLs {
+		r, err := locator.get(client, location, filename)
+		if err != nil {
+			for _, r := range resources {
+				_ = r.Close()
+			}
+			return nil, err
+		}
+		if r != nil {
+			resources = append(resources, r)
+		}
+	}
+	return resources, nil
+}
+
+// get returns the Resource of filename under the location, or nil if the
+// server responds 404.
+func (locator *HTTPResourceLocator) get(client *http.Client, location, filename string) (Resource, error) {
+	fileURL, err := url.JoinPath(location, filename)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if locator.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+locator.BearerToken)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("get %s returns %s", fileURL, resp.Status)
+	}
+	return &httpResource{ReadCloser: resp.Body, name: fileURL}, nil
+}
+
+// httpResource is the body of a http response.
+type httpResource struct {
+	io.ReadCloser
+	name string
+}
+
+func (r *httpResource) Name() string {
+	return r.name
+}
@@ -0,0 +1,279 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package gs
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/limpo1989/go-spring/conf"
+	"github.com/limpo1989/go-spring/internal/utils/assert"
+)
+
+func TestFileResourceLocator_LocateProfiles(t *testing.T) {
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"application.yaml":      "a: 1",
+		"application-dev.yaml":  "a: 2",
+		"application-test.yaml": "a: 3",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), os.ModePerm)
+		assert.Nil(t, err)
+	}
+
+	locator := &FileResourceLocator{ConfigLocations: []string{dir}}
+
+	names := func(resources []Resource) []string {
+		var ret []string
+		for _, r := range resources {
+			ret = append(ret, filepath.Base(r.Name()))
+			_ = r.Close()
+		}
+		return ret
+	}
+
+	t.Run("base only", func(t *testing.T) {
+		resources, err := locator.LocateProfiles("application.yaml", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, names(resources), []string{"application.yaml"})
+
+		resources, err = locator.LocateProfiles("application.yaml", []string{"prod"})
+		assert.Nil(t, err)
+		assert.Equal(t, names(resources), []string{"application.yaml"})
+	})
+
+	t.Run("base and profiles", func(t *testing.T) {
+		resources, err := locator.LocateProfiles("application.yaml", []string{"test", "prod", "dev"})
+		assert.Nil(t, err)
+		assert.Equal(t, names(resources), []string{
+			"application.yaml",
+			"application-test.yaml",
+			"application-dev.yaml",
+		})
+	})
+}
+
+func TestHTTPResourceLocator(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/a/application.properties":
+			_, _ = w.Write([]byte("a=1"))
+		case "/b/application.properties":
+			_, _ = w.Write([]byte("b=2"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	locator := &HTTPResourceLocator{
+		ConfigURLs:  []string{server.URL + "/a", server.URL + "/c", server.URL + "/b/"},
+		Timeout:     time.Second,
+		BearerToken: "abc",
+	}
+
+	resources, err := locator.Locate("application.properties")
+	assert.Nil(t, err)
+	assert.Equal(t, len(resources), 2)
+
+	var names, contents []string
+	for _, r := range resources {
+		b, err := io.ReadAll(r)
+		assert.Nil(t, err)
+		_ = r.Close()
+		names = append(names, r.Name())
+		contents = append(contents, string(b))
+	}
+	assert.Equal(t, names, []string{
+		server.URL + "/a/application.properties",
+		server.URL + "/b/application.properties",
+	})
+	assert.Equal(t, contents, []string{"a=1", "b=2"})
+
+	resources, err = locator.Locate("application-dev.properties")
+	assert.Nil(t, err)
+	assert.Equal(t, len(resources), 0)
+
+	locator.BearerToken = ""
+	_, err = locator.Locate("application.properties")
+	assert.Error(t, err, "get .*/a/application.properties returns 401 Unauthorized")
+
+	// the bodies located before the failure are closed.
+	transport := &closeCountingTransport{RoundTripper: http.DefaultTransport}
+	http.DefaultTransport = transport
+	defer func() { http.DefaultTransport = transport.RoundTripper }()
+
+	locator.BearerToken = "abc"
+	locator.ConfigURLs = []string{server.URL + "/a", server.URL + "/b", server.URL + "/c"}
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/c/application.properties" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("a=1"))
+	})
+	_, err = locator.Locate("application.properties")
+	assert.Error(t, err, "get .*/c/application.properties returns 500 Internal Server Error")
+	assert.Equal(t, transport.closed, 3)
+}
+
+// closeCountingTransport counts the closed response bodies.
+type closeCountingTransport struct {
+	http.RoundTripper
+	closed int
+}
+
+func (t *closeCountingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &closeCountingBody{ReadCloser: resp.Body, closed: &t.closed}
+	return resp, nil
+}
+
+type closeCountingBody struct {
+	io.ReadCloser
+	closed *int
+}
+
+func (b *closeCountingBody) Close() error {
+	*b.closed++
+	return b.ReadCloser.Close()
+}
+
+// mapResourceLocator locate Resource from a map like an embedded file system.
+type mapResourceLocator struct {
+	files map[string]string
+	err   error
+}
+
+type mapResource struct {
+	*bytes.Reader
+	name string
+}
+
+func (r *mapResource) Name() string { return r.name }
+
+func (r *mapResource) Close() error { return nil }
+
+func (l *mapResourceLocator) Locate(filename string) ([]Resource, error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+	content, ok := l.files[filename]
+	if !ok {
+		return nil, nil
+	}
+	return []Resource{&mapResource{Reader: bytes.NewReader([]byte(content)), name: "embed:" + filename}}, nil
+}
+
+func TestLoadResources(t *testing.T) {
+
+	resources := []Resource{
+		&mapResource{Reader: bytes.NewReader([]byte("a: 1\nb: 1")), name: "application.yaml"},
+		&mapResource{Reader: bytes.NewReader([]byte("b=2\nc=2")), name: "application-dev.properties"},
+	}
+
+	props := conf.New()
+	err := LoadResources(props, resources)
+	assert.Nil(t, err)
+	assert.Equal(t, props.Get("a"), "1")
+	assert.Equal(t, props.Get("b"), "2")
+	assert.Equal(t, props.Get("c"), "2")
+	assert.Equal(t, props.Origin("a"), "application.yaml")
+	assert.Equal(t, props.Origin("b"), "application-dev.properties")
+	assert.Equal(t, props.Origin("c"), "application-dev.properties")
+
+	// a key set later in other ways forgets its origin.
+	_ = props.Set("b", "3")
+	assert.Equal(t, props.Origin("b"), "")
+
+	resources = []Resource{
+		&mapResource{Reader: bytes.NewReader([]byte("a: 1")), name: "application.xml"},
+	}
+	err = LoadResources(conf.New(), resources)
+	assert.Error(t, err, "unsupported file type .xml")
+}
+
+func TestCompositeResourceLocator(t *testing.T) {
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("a: 1"), os.ModePerm)
+	assert.Nil(t, err)
+
+	file := &FileResourceLocator{ConfigLocations: []string{dir}}
+	embed := &mapResourceLocator{files: map[string]string{
+		"application.yaml":     "a: 0",
+		"application-dev.yaml": "a: 2",
+	}}
+
+	names := func(resources []Resource) []string {
+		var ret []string
+		for _, r := range resources {
+			ret = append(ret, filepath.Base(r.Name()))
+			_ = r.Close()
+		}
+		return ret
+	}
+
+	t.Run("concatenation", func(t *testing.T) {
+		locator := &CompositeResourceLocator{Locators: []ResourceLocator{file, embed}}
+		resources, err := locator.Locate("application.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, names(resources), []string{"application.yaml", "embed:application.yaml"})
+	})
+
+	t.Run("first found", func(t *testing.T) {
+		locator := &CompositeResourceLocator{Locators: []ResourceLocator{file, embed}, FirstFound: true}
+		resources, err := locator.Locate("application.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, names(resources), []string{"application.yaml"})
+
+		// the file is missing on the file system, falls back to the embedded one.
+		resources, err = locator.Locate("application-dev.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, names(resources), []string{"embed:application-dev.yaml"})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		bad1 := &mapResourceLocator{err: errors.New("error 1")}
+		bad2 := &mapResourceLocator{err: errors.New("error 2")}
+
+		locator := &CompositeResourceLocator{Locators: []ResourceLocator{file, bad1, bad2}}
+		_, err := locator.Locate("application.yaml")
+		assert.Error(t, err, "^error 1$")
+
+		locator.CollectErrors = true
+		_, err = locator.Locate("application.yaml")
+		assert.Error(t, err, "^error 1\nerror 2$")
+	})
+}
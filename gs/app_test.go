@@ -18,9 +18,11 @@ package gs
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/limpo1989/go-spring/conf"
 	"github.com/limpo1989/go-spring/internal/utils/assert"
 )
 
@@ -80,3 +82,22 @@ func TestConfig(t *testing.T) {
 		defer app.Shutdown("run test end")
 	})
 }
+
+func TestConfiguration_ProfileGroup(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "application.properties"), []byte("spring.profiles.group.prod=prod-db"), 0644)
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(dir, "application-prod-db.properties"), []byte("db.url=mysql://prod"), 0644)
+	assert.Nil(t, err)
+
+	Setenv("GS_SPRING_CONFIG_LOCATIONS", dir)
+	Setenv("GS_SPRING_CONFIG_PROFILES", "prod")
+	defer os.Clearenv()
+
+	p := conf.New()
+	err = NewConfiguration(new(FileResourceLocator)).Load(p)
+	assert.Nil(t, err)
+	assert.Equal(t, p.Get("spring.config.profiles"), "prod,prod-db")
+	assert.Equal(t, p.Get("db.url"), "mysql://prod")
+}
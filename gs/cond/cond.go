@@ -22,6 +22,7 @@ package cond
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -98,26 +99,18 @@ func (c *onProperty) Matches(ctx Context) (bool, error) {
 	}
 
 	val := ctx.Prop(c.name)
+	if strings.HasPrefix(c.havingValue, "re:") {
+		r, err := regexp.Compile(c.havingValue[3:])
+		if err != nil {
+			return false, err
+		}
+		return r.MatchString(val), nil
+	}
 	if !strings.HasPrefix(c.havingValue, "go:") {
 		return val == c.havingValue, nil
 	}
 
-	getValue := func(val string) interface{} {
-		if b, err := strconv.ParseBool(val); err == nil {
-			return b
-		}
-		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
-			return i
-		}
-		if u, err := strconv.ParseUint(val, 10, 64); err == nil {
-			return u
-		}
-		if f, err := strconv.ParseFloat(val, 64); err == nil {
-			return f
-		}
-		return val
-	}
-	r, err := expr.Eval(c.havingValue[3:], map[string]interface{}{"$": getValue(val)})
+	r, err := expr.Eval(c.havingValue[3:], map[string]interface{}{"$": exprValue(val)})
 	if nil != err {
 		return false, err
 	}
@@ -129,6 +122,37 @@ func (c *onProperty) Matches(ctx Context) (bool, error) {
 	return b, nil
 }
 
+// exprValue converts a property value to bool, int64, uint64 or float64 when
+// possible, so that it can be compared with numbers in expressions.
+func exprValue(val string) interface{} {
+	if b, err := strconv.ParseBool(val); err == nil {
+		return b
+	}
+	return propValue(val)
+}
+
+// propValue converts a property value to int64, uint64 or float64 when
+// possible, only true and false are bools, so that values like 1 and 0 are
+// compared as numbers.
+func propValue(val string) interface{} {
+	switch val {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(val, 10, 64); err == nil {
+		return u
+	}
+	if f, err := strconv.ParseFloat(val, 64); err == nil {
+		return f
+	}
+	return val
+}
+
 // onMissingProperty is a Condition that returns true when a property doesn't exist.
 type onMissingProperty struct {
 	name string
@@ -138,6 +162,70 @@ func (c *onMissingProperty) Matches(ctx Context) (bool, error) {
 	return !ctx.Has(c.name), nil
 }
 
+// onPropertyPrefix is a Condition that returns true when any property under
+// a prefix exists.
+type onPropertyPrefix struct {
+	prefix string
+}
+
+func (c *onPropertyPrefix) Matches(ctx Context) (bool, error) {
+	kc, ok := ctx.(keysContext)
+	if !ok {
+		return false, fmt.Errorf("prefix %q error: context doesn't provide Keys", c.prefix)
+	}
+	// a leaf like db=x has no property under it.
+	prefix := strings.TrimSuffix(c.prefix, ".")
+	for _, key := range kc.Keys() {
+		if strings.HasPrefix(key, prefix+".") || strings.HasPrefix(key, prefix+"[") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// onProfile is a Condition that returns true when a profile is active.
+type onProfile struct {
+	profile string
+}
+
+func (c *onProfile) Matches(ctx Context) (bool, error) {
+
+	if !ctx.Has(ProfilesKey) {
+		return false, nil
+	}
+
+	active := strings.Split(ctx.Prop(ProfilesKey), ",")
+	profiles := ExpandProfiles(active, func(profile string) []string {
+		return propList(ctx, ProfileGroupPrefix+profile)
+	})
+	for _, profile := range profiles {
+		if profile == c.profile {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// propList returns a property's value defined as a comma separated string or as
+// a list.
+func propList(ctx Context, key string) []string {
+	if !ctx.Has(key) {
+		return nil
+	}
+	if val := ctx.Prop(key); val != "" {
+		return strings.Split(val, ",")
+	}
+	var ret []string
+	for i := 0; ; i++ {
+		k := fmt.Sprintf("%s[%d]", key, i)
+		if !ctx.Has(k) {
+			break
+		}
+		ret = append(ret, ctx.Prop(k))
+	}
+	return ret
+}
+
 // onBean is a Condition that returns true when finding more than one beans.
 type onBean struct {
 	selector BeanSelector
@@ -173,8 +261,34 @@ type onExpression struct {
 	expression string
 }
 
+// keysContext is implemented by the Contexts which can list all keys of the
+// properties, it's optional for Context and needed by OnExpression.
+type keysContext interface {
+	Keys() []string
+}
+
 func (c *onExpression) Matches(ctx Context) (bool, error) {
-	return false, UnimplementedMethod
+
+	kc, ok := ctx.(keysContext)
+	if !ok {
+		return false, fmt.Errorf("eval %q error: context doesn't provide Keys", c.expression)
+	}
+
+	props := make(map[string]interface{})
+	for _, key := range kc.Keys() {
+		props[key] = propValue(ctx.Prop(key))
+	}
+
+	r, err := expr.Eval(c.expression, map[string]interface{}{"props": props})
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := r.(bool)
+	if !ok {
+		return false, fmt.Errorf("eval %q doesn't return bool", c.expression)
+	}
+	return b, nil
 }
 
 // Operator defines operation between conditions, including Or、And、None.
@@ -336,6 +450,9 @@ func MatchIfMissing() PropertyOption {
 }
 
 // HavingValue sets a Condition to return true when property value equals to havingValue.
+// The havingValue starts with "go:" is an expression evaluated with the value as $,
+// like "go:$>10", and starts with "re:" is a regular expression the raw value must
+// match, like "re:^v[0-9]+$".
 func HavingValue(havingValue string) PropertyOption {
 	return func(c *onProperty) {
 		c.havingValue = havingValue
@@ -348,7 +465,8 @@ func OnProperty(name string, options ...PropertyOption) *conditional {
 	return New().OnProperty(name, options...)
 }
 
-// OnProperty adds a Condition that checks a property and its value.
+// OnProperty adds a Condition that checks a property and its value, the name is a
+// key as binding uses, such as "servers[0].host".
 func (c *conditional) OnProperty(name string, options ...PropertyOption) *conditional {
 	cond := &onProperty{name: name}
 	for _, option := range options {
@@ -368,6 +486,20 @@ func (c *conditional) OnMissingProperty(name string) *conditional {
 	return c.On(&onMissingProperty{name: name})
 }
 
+// OnPropertyPrefix returns a conditional that starts with a Condition that returns
+// true when any property under the prefix exists.
+func OnPropertyPrefix(prefix string) *conditional {
+	return New().OnPropertyPrefix(prefix)
+}
+
+// OnPropertyPrefix adds a Condition that returns true when any property under the
+// prefix exists, such as "db" for db.url or "servers[0]" for servers[0].host. A
+// property named by the prefix itself doesn't count. Like OnExpression, it needs
+// the Context to have a `Keys() []string` method.
+func (c *conditional) OnPropertyPrefix(prefix string) *conditional {
+	return c.On(&onPropertyPrefix{prefix: prefix})
+}
+
 // OnBean returns a conditional that starts with a Condition that returns true when
 // finding more than one beans.
 func OnBean(selector BeanSelector) *conditional {
@@ -408,6 +540,14 @@ func OnExpression(expression string) *conditional {
 }
 
 // OnExpression adds a Condition that returns true when an expression returns true.
+// The expression can access the properties by their keys through props, values
+// are converted to numbers when possible, and true and false to bools, for
+// example:
+//
+//	props["env"] == "prod" && props["replicas"] > 2
+//
+// It needs the Context to have a `Keys() []string` method listing the keys of
+// the properties, like the one of the IoC container.
 func (c *conditional) OnExpression(expression string) *conditional {
 	return c.On(&onExpression{expression: expression})
 }
@@ -429,7 +569,40 @@ func OnProfile(profile string) *conditional {
 	return New().OnProfile(profile)
 }
 
-// OnProfile adds a Condition that returns true when property value equals to profile.
+// OnProfile adds a Condition that returns true when profile is one of the active
+// profiles, including the members of active profile groups.
 func (c *conditional) OnProfile(profile string) *conditional {
-	return c.OnProperty("spring.config.profiles", HavingValue(profile))
+	return c.On(&onProfile{profile: profile})
+}
+
+const (
+	// ProfilesKey is the property that defines the active profiles.
+	ProfilesKey = "spring.config.profiles"
+	// ProfileGroupPrefix is the prefix of properties that define profile groups,
+	// for example, spring.profiles.group.prod=prod-db,prod-cache means activating
+	// prod implies prod-db and prod-cache.
+	ProfileGroupPrefix = "spring.profiles.group."
+)
+
+// ExpandProfiles expands the profile groups recursively, each profile is followed
+// by its group members, and appears only once in the result.
+func ExpandProfiles(profiles []string, group func(profile string) []string) []string {
+	var (
+		result  []string
+		visited = make(map[string]bool)
+		expand  func(profiles []string)
+	)
+	expand = func(profiles []string) {
+		for _, profile := range profiles {
+			profile = strings.TrimSpace(profile)
+			if profile == "" || visited[profile] {
+				continue
+			}
+			visited[profile] = true
+			result = append(result, profile)
+			expand(group(profile))
+		}
+	}
+	expand(profiles)
+	return result
 }
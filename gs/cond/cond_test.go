@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/limpo1989/go-spring/conf"
 	"github.com/limpo1989/go-spring/internal/utils"
 	"github.com/limpo1989/go-spring/internal/utils/assert"
 )
@@ -191,6 +192,46 @@ func TestOnProperty(t *testing.T) {
 			ctrl.Finish()
 		}
 	})
+	t.Run("regexp", func(t *testing.T) {
+		testcases := []struct {
+			propValue    string
+			expression   string
+			expectResult bool
+		}{
+			{
+				"v12",
+				"re:^v[0-9]+$",
+				true,
+			},
+			{
+				"12",
+				"re:^[0-9]{2}$",
+				true,
+			},
+			{
+				"x12",
+				"re:^v[0-9]+$",
+				false,
+			},
+		}
+		for _, testcase := range testcases {
+			ctrl := gomock.NewController(t)
+			ctx := NewMockContext(ctrl)
+			ctx.EXPECT().Has("a").Return(true)
+			ctx.EXPECT().Prop("a").Return(testcase.propValue)
+			ok, err := OnProperty("a", HavingValue(testcase.expression)).Matches(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, ok, testcase.expectResult)
+			ctrl.Finish()
+		}
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		ctx := NewMockContext(ctrl)
+		ctx.EXPECT().Has("a").Return(true)
+		ctx.EXPECT().Prop("a").Return("v1")
+		_, err := OnProperty("a", HavingValue("re:v[")).Matches(ctx)
+		assert.Error(t, err, "error parsing regexp")
+	})
 }
 
 func TestOnMissingProperty(t *testing.T) {
@@ -214,6 +255,52 @@ func TestOnMissingProperty(t *testing.T) {
 	})
 }
 
+// propContext is a Context backed by conf.Properties.
+type propContext struct {
+	*conf.Properties
+}
+
+func (c propContext) Prop(key string, opts ...conf.GetOption) string {
+	return c.Get(key, opts...)
+}
+
+func (c propContext) Find(selector BeanSelector) ([]BeanDefinition, error) {
+	return nil, nil
+}
+
+func TestOnProperty_Nested(t *testing.T) {
+	ctx := propContext{conf.Map(map[string]interface{}{
+		"servers": []interface{}{
+			map[string]interface{}{"host": "a.com", "port": 80},
+		},
+		"db": map[string]interface{}{
+			"url": "mysql://localhost",
+		},
+		"cache": "redis",
+	})}
+	for _, c := range []struct {
+		cond   Condition
+		expect bool
+	}{
+		{OnProperty("servers[0].host"), true},
+		{OnProperty("servers[0].host", HavingValue("a.com")), true},
+		{OnProperty("servers[1].host"), false},
+		{OnProperty("db.url"), true},
+		{OnMissingProperty("servers[0].port"), false},
+		{OnMissingProperty("servers[1].port"), true},
+		{OnPropertyPrefix("servers"), true},
+		{OnPropertyPrefix("servers[0]"), true},
+		{OnPropertyPrefix("db."), true},
+		{OnPropertyPrefix("cache"), false},
+		{OnPropertyPrefix("none"), false},
+		{OnPropertyPrefix("db.url"), false},
+	} {
+		ok, err := c.cond.Matches(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, ok, c.expect)
+	}
+}
+
 func TestOnBean(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -347,12 +434,43 @@ func TestOnSingleBean(t *testing.T) {
 }
 
 func TestOnExpression(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-	ctx := NewMockContext(ctrl)
-	ok, err := OnExpression("").Matches(ctx)
-	assert.Error(t, err, "unimplemented method")
-	assert.False(t, ok)
+	t.Run("props", func(t *testing.T) {
+		ctx := propContext{conf.Map(map[string]interface{}{
+			"env":      "prod",
+			"replicas": 3,
+		})}
+		ok, err := OnExpression(`props["env"] == "prod" && props["replicas"] > 2`).Matches(ctx)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, err = OnExpression(`props["env"] == "prod" && props["replicas"] > 3`).Matches(ctx)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("numbers", func(t *testing.T) {
+		for val, expect := range map[string]bool{"1": true, "0": false} {
+			ctx := propContext{conf.Map(map[string]interface{}{
+				"enabled":  "true",
+				"replicas": val,
+			})}
+			ok, err := OnExpression(`props["enabled"] && props["replicas"] > 0`).Matches(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, ok, expect)
+		}
+	})
+	t.Run("not bool", func(t *testing.T) {
+		ctx := propContext{conf.Map(map[string]interface{}{"env": "prod"})}
+		ok, err := OnExpression(`props["env"]`).Matches(ctx)
+		assert.Error(t, err, "doesn't return bool")
+		assert.False(t, ok)
+	})
+	t.Run("no keys", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		ctx := NewMockContext(ctrl)
+		ok, err := OnExpression(`true`).Matches(ctx)
+		assert.Error(t, err, "context doesn't provide Keys")
+		assert.False(t, ok)
+	})
 }
 
 func TestOnMatches(t *testing.T) {
@@ -382,6 +500,7 @@ func TestOnProfile(t *testing.T) {
 		ctx := NewMockContext(ctrl)
 		ctx.EXPECT().Has("spring.config.profiles").Return(true)
 		ctx.EXPECT().Prop("spring.config.profiles").Return("dev")
+		ctx.EXPECT().Has("spring.profiles.group.dev").Return(false)
 		ok, err := OnProfile("test").Matches(ctx)
 		assert.Nil(t, err)
 		assert.False(t, ok)
@@ -392,10 +511,44 @@ func TestOnProfile(t *testing.T) {
 		ctx := NewMockContext(ctrl)
 		ctx.EXPECT().Has("spring.config.profiles").Return(true)
 		ctx.EXPECT().Prop("spring.config.profiles").Return("test")
+		ctx.EXPECT().Has("spring.profiles.group.test").Return(false)
 		ok, err := OnProfile("test").Matches(ctx)
 		assert.Nil(t, err)
 		assert.True(t, ok)
 	})
+	t.Run("group property", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		ctx := NewMockContext(ctrl)
+		ctx.EXPECT().Has("spring.config.profiles").Return(true).Times(2)
+		ctx.EXPECT().Prop("spring.config.profiles").Return("dev,prod").Times(2)
+		ctx.EXPECT().Has("spring.profiles.group.dev").Return(false).Times(2)
+		ctx.EXPECT().Has("spring.profiles.group.prod").Return(true).Times(2)
+		ctx.EXPECT().Prop("spring.profiles.group.prod").Return("").Times(2)
+		ctx.EXPECT().Has("spring.profiles.group.prod[0]").Return(true).Times(2)
+		ctx.EXPECT().Prop("spring.profiles.group.prod[0]").Return("prod-db").Times(2)
+		ctx.EXPECT().Has("spring.profiles.group.prod[1]").Return(false).Times(2)
+		ctx.EXPECT().Has("spring.profiles.group.prod-db").Return(false).Times(2)
+		ok, err := OnProfile("prod-db").Matches(ctx)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, err = OnProfile("prod-cache").Matches(ctx)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestExpandProfiles(t *testing.T) {
+	groups := map[string][]string{
+		"prod":     {"prod-db", "prod-cache"},
+		"prod-db":  {"mysql", "prod"},
+		"mysql":    nil,
+		"postgres": {"sql"},
+	}
+	profiles := ExpandProfiles([]string{"dev", " prod", "mysql"}, func(profile string) []string {
+		return groups[profile]
+	})
+	assert.Equal(t, profiles, []string{"dev", "prod", "prod-db", "mysql", "prod-cache"})
 }
 
 func TestConditional(t *testing.T) {
@@ -2409,6 +2409,27 @@ func TestDefaultSpringContext(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("bean:test_ctx:group", func(t *testing.T) {
+		c := New()
+		p := conf.New()
+		p.Set("spring.config.profiles", "prod")
+		p.Set("spring.profiles.group.prod", "prod-db,prod-cache")
+		c.Object(&BeanZero{5}).On(cond.OnProfile("prod-cache"))
+		c.Object(&BeanOne{}).On(cond.OnProfile("test"))
+
+		err := c.Properties().Refresh(p)
+		assert.Nil(t, err)
+		err = runTest(c, func(p Context) {
+			var b *BeanZero
+			err = p.Get(&b)
+			assert.Nil(t, err)
+			var o *BeanOne
+			err = p.Get(&o)
+			assert.Error(t, err, "can't find bean, bean:\"\"")
+		})
+		assert.Nil(t, err)
+	})
+
 	t.Run("option withClassName Condition", func(t *testing.T) {
 
 		c := New()
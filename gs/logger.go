@@ -20,10 +20,33 @@ import "github.com/limpo1989/go-spring/internal/log"
 
 type Logger = log.Logger
 
+type Level = log.Level
+
+type MetricsHook = log.MetricsHook
+
 func SetLogger(loggerName string, logger *Logger, primary ...bool) {
 	log.SetLogger(loggerName, logger, primary...)
 }
 
+// GetLogger returns the logger registered with the name, or the one it inherits,
+// an unknown name gets the primary logger instead of nil.
 func GetLogger(loggerName string, typeName string) *Logger {
 	return log.GetLogger(loggerName, typeName)
 }
+
+// SetLoggerLevel changes the level of a named logger at runtime.
+func SetLoggerLevel(loggerName string, level Level) error {
+	return log.SetLoggerLevel(loggerName, level)
+}
+
+// ParseLevel parses a level name case-insensitively, aliases and numeric levels
+// are also accepted.
+func ParseLevel(s string) (Level, error) {
+	return log.ParseLevel(s)
+}
+
+// SetMetricsHook sets the hook called for every emitted message of the named
+// loggers, nil removes it.
+func SetMetricsHook(hook MetricsHook) {
+	log.SetMetricsHook(hook)
+}
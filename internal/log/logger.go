@@ -17,15 +17,21 @@
 package log
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 type Logger = slog.Logger
 
+type Level = slog.Level
+
 var loggers sync.Map
 
 func init() {
@@ -56,11 +62,14 @@ func init() {
 
 type namedLogger struct {
 	name   string
+	level  *loggerLevel
 	logger *Logger
 }
 
 func SetLogger(loggerName string, logger *Logger, primary ...bool) {
-	named := &namedLogger{name: loggerName, logger: logger}
+	level := new(loggerLevel)
+	handler := &levelHandler{name: loggerName, level: level, handler: logger.Handler()}
+	named := &namedLogger{name: loggerName, level: level, logger: slog.New(handler)}
 	loggers.Store(loggerName, named)
 
 	if len(primary) > 0 && primary[0] {
@@ -68,10 +77,141 @@ func SetLogger(loggerName string, logger *Logger, primary ...bool) {
 	}
 }
 
+// GetLogger returns the logger registered with the name. A name without its
+// own logger inherits the nearest registered ancestor of the dotted name, like
+// "com.app" then "com" for "com.app.db", and finally the primary logger. The
+// level changes of the ancestor also take effect on it.
+//
+// Any unknown name, dotted or not, gets the primary logger, so callers can't
+// check nil to find out whether a name is registered, use LookupLogger for
+// it instead. It returns nil only if there is no primary logger.
 func GetLogger(loggerName string, typeName string) *Logger {
+	named, inherited := findLogger(loggerName)
+	if named == nil {
+		return nil
+	}
+	name, logger := named.name, named.logger
+	if inherited {
+		// the handler shares the level of the ancestor, but reports the
+		// requested name to the metrics hook.
+		h := *logger.Handler().(*levelHandler)
+		h.name = loggerName
+		name, logger = loggerName, slog.New(&h)
+	}
+	return logger.With("logger", name, "type", filepath.Base(typeName))
+}
+
+// findLogger returns the logger registered with the name, or the nearest one
+// the name inherits, and whether it's inherited.
+func findLogger(loggerName string) (*namedLogger, bool) {
+	name := loggerName
+	for {
+		if l, ok := loggers.Load(name); ok {
+			return l.(*namedLogger), name != loggerName
+		}
+		if name == "" {
+			return nil, false
+		}
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[:i]
+		} else {
+			name = "" // the primary logger is the root of all names.
+		}
+	}
+}
+
+// LookupLogger returns the logger registered with the name, and false if no
+// logger is registered, unlike GetLogger the result has no type attribute.
+func LookupLogger(loggerName string) (*Logger, bool) {
 	if l, ok := loggers.Load(loggerName); ok {
 		named := l.(*namedLogger)
-		return named.logger.With("logger", named.name, "type", filepath.Base(typeName))
+		return named.logger.With("logger", named.name), true
+	}
+	return nil, false
+}
+
+// SetLoggerLevel changes the level of a named logger at runtime, it also takes
+// effect on the loggers returned by GetLogger before.
+func SetLoggerLevel(loggerName string, level Level) error {
+	l, ok := loggers.Load(loggerName)
+	if !ok {
+		return fmt.Errorf("logger %q not found", loggerName)
 	}
+	named := l.(*namedLogger)
+	named.level.level.Set(level)
+	named.level.set.Store(true)
 	return nil
 }
+
+// ParseLevel parses a level name case-insensitively, it accepts the slog names
+// with optional offsets like "info+2", the aliases "warning", "err" and "error",
+// and numeric levels like "-4" or "8".
+func ParseLevel(s string) (Level, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return Level(n), nil
+	}
+	switch strings.ToLower(s) {
+	case "warning":
+		return slog.LevelWarn, nil
+	case "err":
+		return slog.LevelError, nil
+	}
+	var level Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", s)
+	}
+	return level, nil
+}
+
+// MetricsHook is called with the level and the name of the logger for every
+// emitted message, e.g. to count messages by level.
+type MetricsHook func(level Level, loggerName string)
+
+var metricsHook atomic.Pointer[MetricsHook]
+
+// SetMetricsHook sets the hook called for every emitted message of the named
+// loggers, the messages filtered out by the level don't call it. The hook must
+// be cheap and safe for concurrent use, nil removes it.
+func SetMetricsHook(hook MetricsHook) {
+	if hook == nil {
+		metricsHook.Store(nil)
+		return
+	}
+	metricsHook.Store(&hook)
+}
+
+// loggerLevel overrides the level of the underlying handler once it is set.
+type loggerLevel struct {
+	set   atomic.Bool
+	level slog.LevelVar
+}
+
+// levelHandler is a slog.Handler whose level can be changed at runtime.
+type levelHandler struct {
+	name    string
+	level   *loggerLevel
+	handler slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.level.set.Load() {
+		return level >= h.level.level.Level()
+	}
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if hook := metricsHook.Load(); hook != nil {
+		(*hook)(r.Level, h.name)
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{name: h.name, level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{name: h.name, level: h.level, handler: h.handler.WithGroup(name)}
+}
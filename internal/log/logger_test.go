@@ -0,0 +1,188 @@
+/*
+ * Copyright 2012-2019 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/limpo1989/go-spring/internal/utils/assert"
+)
+
+func TestSetLoggerLevel(t *testing.T) {
+
+	var buf bytes.Buffer
+	SetLogger("level", slog.New(slog.NewTextHandler(&buf, nil)))
+
+	l := GetLogger("level", "log.Test")
+	l.Debug("debug before")
+	l.Info("info before")
+	assert.String(t, buf.String()).Contains("info before")
+	assert.False(t, bytes.Contains(buf.Bytes(), []byte("debug before")))
+
+	err := SetLoggerLevel("level", slog.LevelDebug)
+	assert.Nil(t, err)
+
+	buf.Reset()
+	l.Debug("debug after")
+	assert.String(t, buf.String()).Contains("debug after")
+
+	err = SetLoggerLevel("level", slog.LevelWarn)
+	assert.Nil(t, err)
+
+	buf.Reset()
+	GetLogger("level", "log.Test").Info("info after")
+	l.Warn("warn after")
+	assert.False(t, bytes.Contains(buf.Bytes(), []byte("info after")))
+	assert.String(t, buf.String()).Contains("warn after")
+
+	err = SetLoggerLevel("unknown", slog.LevelDebug)
+	assert.Error(t, err, "logger \"unknown\" not found")
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, c := range []struct {
+		s     string
+		level Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"DEBUG", slog.LevelDebug},
+		{"Info", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"WARNING", slog.LevelWarn},
+		{"err", slog.LevelError},
+		{"error", slog.LevelError},
+		{"info+2", slog.LevelInfo + 2},
+		{" -4 ", slog.LevelDebug},
+		{"12", Level(12)},
+	} {
+		level, err := ParseLevel(c.s)
+		assert.Nil(t, err)
+		assert.Equal(t, level, c.level)
+	}
+	_, err := ParseLevel("verbose")
+	assert.Error(t, err, "unknown log level \"verbose\"")
+}
+
+func TestGetLogger_Inherit(t *testing.T) {
+
+	primary, _ := loggers.Load("")
+	defer loggers.Store("", primary)
+
+	var app, db, root bytes.Buffer
+	SetLogger("com.app", slog.New(slog.NewTextHandler(&app, nil)))
+	SetLogger("com.app.db", slog.New(slog.NewTextHandler(&db, nil)))
+	SetLogger("inherit-root", slog.New(slog.NewTextHandler(&root, nil)), true)
+
+	GetLogger("com.app.db", "log.Test").Info("exact")
+	assert.String(t, db.String()).Contains("msg=exact logger=com.app.db")
+
+	GetLogger("com.app.web.api", "log.Test").Info("parent")
+	assert.String(t, app.String()).Contains("msg=parent logger=com.app.web.api")
+
+	GetLogger("org.other", "log.Test").Info("root")
+	assert.String(t, root.String()).Contains("msg=root logger=org.other")
+
+	// undotted names fall back to the primary logger too.
+	root.Reset()
+	GetLogger("other", "log.Test").Info("undotted")
+	assert.String(t, root.String()).Contains("msg=undotted logger=other")
+
+	loggers.Delete("")
+	assert.Nil(t, GetLogger("other", "log.Test"))
+	assert.Nil(t, GetLogger("org.other", "log.Test"))
+	loggers.Store("", primary)
+
+	err := SetLoggerLevel("com.app", slog.LevelWarn)
+	assert.Nil(t, err)
+	app.Reset()
+	GetLogger("com.app.web", "log.Test").Info("filtered")
+	assert.Equal(t, app.String(), "")
+}
+
+func TestSetMetricsHook(t *testing.T) {
+
+	counts := make(map[string]int)
+	SetMetricsHook(func(level Level, loggerName string) {
+		counts[fmt.Sprintf("%s:%s", loggerName, level)]++
+	})
+	defer SetMetricsHook(nil)
+
+	SetLogger("metrics", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	l := GetLogger("metrics", "log.Test")
+	l.Debug("filtered")
+	l.Info("info")
+	l.Info("info")
+	l.Error("error")
+	assert.Equal(t, counts, map[string]int{
+		"metrics:INFO":  2,
+		"metrics:ERROR": 1,
+	})
+
+	// inherited loggers are counted by their own names.
+	GetLogger("metrics.db", "log.Test").Info("info")
+	assert.Equal(t, counts["metrics.db:INFO"], 1)
+	assert.Equal(t, counts["metrics:INFO"], 2)
+
+	SetMetricsHook(nil)
+	l.Info("no hook")
+	assert.Equal(t, counts["metrics:INFO"], 2)
+}
+
+func TestLookupLogger(t *testing.T) {
+
+	l, ok := LookupLogger("lookup")
+	assert.False(t, ok)
+	assert.Nil(t, l)
+
+	_, ok = loggers.Load("lookup")
+	assert.False(t, ok)
+
+	var buf bytes.Buffer
+	SetLogger("lookup", slog.New(slog.NewTextHandler(&buf, nil)))
+
+	l, ok = LookupLogger("lookup")
+	assert.True(t, ok)
+	l.Info("hello")
+	assert.String(t, buf.String()).Contains("msg=hello logger=lookup")
+}
+
+func TestLogger_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("concurrent-%d", i%4)
+			SetLogger(name, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			_ = SetLoggerLevel(name, slog.LevelDebug)
+			if l := GetLogger(name, "log.Test"); l != nil {
+				l.Debug("concurrent")
+			}
+			_, _ = LookupLogger(name)
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 4; i++ {
+		_, ok := LookupLogger(fmt.Sprintf("concurrent-%d", i))
+		assert.True(t, ok)
+	}
+}
@@ -84,7 +84,7 @@ func IsConverter(t reflect.Type) bool {
 		t.NumIn() == 1 &&
 		t.In(0).Kind() == reflect.String &&
 		t.NumOut() == 2 &&
-		(IsValueType(t.Out(0)) || IsFuncType(t.Out(0))) && IsErrorType(t.Out(1))
+		(IsValueType(t.Out(0)) || IsStructPtr(t.Out(0)) || IsFuncType(t.Out(0))) && IsErrorType(t.Out(1))
 }
 
 // IsFuncType returns whether `t` is func type.
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"reflect"
 	"testing"
@@ -500,6 +501,7 @@ func TestIsConverter(t *testing.T) {
 	assert.False(t, IsConverter(reflect.TypeOf(func(key string) {})))
 	assert.False(t, IsConverter(reflect.TypeOf(func(key string) string { return "" })))
 	assert.True(t, IsConverter(reflect.TypeOf(func(key string) (string, error) { return "", nil })))
+	assert.True(t, IsConverter(reflect.TypeOf(func(key string) (*net.IPNet, error) { return nil, nil })))
 }
 
 func TestIsErrorType(t *testing.T) {